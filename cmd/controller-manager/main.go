@@ -3,17 +3,28 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"maps"
 	"os"
+	"strings"
+	"time"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	configv1alpha1 "github.com/AKI-25/certaur/pkg/config/v1alpha1"
 	controller "github.com/AKI-25/certaur/pkg/controllers/certificate"
+	issuercontroller "github.com/AKI-25/certaur/pkg/controllers/issuer"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	issuerutil "github.com/AKI-25/certaur/pkg/util/issuer"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
 	webhook "github.com/AKI-25/certaur/pkg/webhook"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -37,6 +48,12 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var clusterResourceNamespace string
+	var minCertificateDuration, maxCertificateDuration time.Duration
+	var certificateBackdate time.Duration
+	var secretRenameGracePeriod time.Duration
+	var watchNamespaces string
+	var configFile string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -48,6 +65,21 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&clusterResourceNamespace, "cluster-resource-namespace", issuerutil.DefaultClusterResourceNamespace,
+		"The namespace holding the CA secrets referenced by ClusterIssuers.")
+	flag.DurationVar(&minCertificateDuration, "min-certificate-duration", configv1alpha1.DefaultMinDuration,
+		"The shortest certificate lifetime accepted by the webhook.")
+	flag.DurationVar(&maxCertificateDuration, "max-certificate-duration", configv1alpha1.DefaultMaxDuration,
+		"The longest certificate lifetime accepted by the webhook.")
+	flag.DurationVar(&certificateBackdate, "certificate-backdate", certificateutil.DefaultBackdate,
+		"How long the NotBefore of issued certificates and generated root CAs is set in the past to tolerate clock skew.")
+	flag.DurationVar(&secretRenameGracePeriod, "secret-rename-grace-period", configv1alpha1.DefaultSecretRenameGracePeriod,
+		"How long the previous secret of a Certificate is kept after its secretRef has been renamed.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated list of the namespaces watched by the controller manager, all namespaces are watched when empty. "+
+			"The cluster resource namespace is always watched.")
+	flag.StringVar(&configFile, "config", "",
+		"The path of the CertaurConfiguration file. The flags above take precedence over the file when they are set.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -56,6 +88,30 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	cfg, err := configv1alpha1.Load(configFile)
+	if err != nil {
+		setupLog.Error(err, "unable to load the configuration")
+		os.Exit(1)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "cluster-resource-namespace":
+			cfg.ClusterResourceNamespace = clusterResourceNamespace
+		case "min-certificate-duration":
+			cfg.Webhook.MinDuration.Duration = minCertificateDuration
+		case "max-certificate-duration":
+			cfg.Webhook.MaxDuration.Duration = maxCertificateDuration
+		case "certificate-backdate":
+			cfg.Controller.Backdate.Duration = certificateBackdate
+		case "secret-rename-grace-period":
+			cfg.Controller.SecretRenameGracePeriod.Duration = secretRenameGracePeriod
+		}
+	})
+	if err := configv1alpha1.Validate(cfg).ToAggregate(); err != nil {
+		setupLog.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+
 	disableHTTP2 := func(c *tls.Config) {
 		setupLog.Info("disabling http/2")
 		c.NextProtos = []string{"http/1.1"}
@@ -79,8 +135,19 @@ func main() {
 		metricsServerOptions.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
 
+	namespaces := defaultNamespaces(watchNamespaces)
+	// The cluster resource namespace is only watched for the CA secrets of ClusterIssuers
+	secretCacheOptions := secretutil.CacheOptions()
+	secretCacheOptions.Namespaces = secretNamespaces(namespaces, cfg.ClusterResourceNamespace)
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
+		Scheme: scheme,
+		// Only cache the secrets managed by certaur, the other secrets are read with the API reader
+		Cache: cache.Options{
+			DefaultNamespaces: namespaces,
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Secret{}: secretCacheOptions,
+			},
+		},
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -92,18 +159,60 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Client reading the secrets missing from the cache with the API reader
+	secretClient := secretutil.NewFallbackClient(mgr.GetClient(), mgr.GetAPIReader())
+	// Metadata of every secret, watched for the secrets read by the controllers that are missing from the cache
+	secretMetadataCache, err := secretutil.NewMetadataCache(mgr, secretCacheOptions.Namespaces)
+	if err != nil {
+		setupLog.Error(err, "unable to create secret metadata cache")
+		os.Exit(1)
+	}
+
 	if err = (&controller.CertificateReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Logger:   mgr.GetLogger(),
-		Recorder: mgr.GetEventRecorderFor("certaur-controller"),
+		Client:                   secretClient,
+		Scheme:                   mgr.GetScheme(),
+		Logger:                   mgr.GetLogger(),
+		Recorder:                 mgr.GetEventRecorderFor("certaur-controller"),
+		ClusterResourceNamespace: cfg.ClusterResourceNamespace,
+		Backdate:                 cfg.Controller.Backdate.Duration,
+		SecretRenameGracePeriod:  cfg.Controller.SecretRenameGracePeriod.Duration,
+		MaxConcurrentReconciles:  cfg.Controller.MaxConcurrentReconciles,
+		MaxIssuanceBackoff:       cfg.Controller.MaxIssuanceBackoff.Duration,
+		SecretMetadataCache:      secretMetadataCache,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Certificate")
 		os.Exit(1)
 	}
 
+	if err = (&issuercontroller.IssuerReconciler{
+		Client:              secretClient,
+		Scheme:              mgr.GetScheme(),
+		Logger:              mgr.GetLogger(),
+		Recorder:            mgr.GetEventRecorderFor("certaur-controller"),
+		Backdate:            cfg.Controller.Backdate.Duration,
+		SecretMetadataCache: secretMetadataCache,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Issuer")
+		os.Exit(1)
+	}
+
+	if err = (&issuercontroller.ClusterIssuerReconciler{
+		Client:                   secretClient,
+		Scheme:                   mgr.GetScheme(),
+		Logger:                   mgr.GetLogger(),
+		Recorder:                 mgr.GetEventRecorderFor("certaur-controller"),
+		ClusterResourceNamespace: cfg.ClusterResourceNamespace,
+		Backdate:                 cfg.Controller.Backdate.Duration,
+		SecretMetadataCache:      secretMetadataCache,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterIssuer")
+		os.Exit(1)
+	}
+
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
-		if err = (webhook.Validator{}).SetupWebhookWithManager(mgr); err != nil {
+		if err = (webhook.Validator{
+			Config: cfg,
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Certificate")
 			os.Exit(1)
 		}
@@ -124,3 +233,32 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// defaultNamespaces returns the namespaces cached by the manager from the comma-separated list of watched
+// namespaces. It returns nil to watch all namespaces when the list is empty
+func defaultNamespaces(watchNamespaces string) map[string]cache.Config {
+	namespaces := map[string]cache.Config{}
+	for _, namespace := range strings.Split(watchNamespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces[namespace] = cache.Config{}
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil
+	}
+	return namespaces
+}
+
+// secretNamespaces returns the namespaces of the secrets cached by the manager, the watched namespaces
+// along with the cluster resource namespace holding the CA secrets of ClusterIssuers. Certificates and
+// Issuers are not cached in the cluster resource namespace unless it is watched, as the namespaced
+// roles only grant access to its secrets. It returns nil to watch all namespaces
+func secretNamespaces(namespaces map[string]cache.Config, clusterResourceNamespace string) map[string]cache.Config {
+	if namespaces == nil {
+		return nil
+	}
+
+	secretNamespaces := maps.Clone(namespaces)
+	secretNamespaces[clusterResourceNamespace] = cache.Config{}
+	return secretNamespaces
+}
@@ -24,27 +24,368 @@ import (
 // +kubebuilder:object:generate=true
 type CertificateSpec struct {
 	// DNS specifies the DNS name for the certificate
+	// Deprecated: use DNSNames instead, DnsName is kept for backward compatibility
 	DnsName string `json:"dnsName,omitempty"`
+	// DNSNames specifies the DNS names the certificate is valid for
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// IPAddresses specifies the IP addresses the certificate is valid for
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+	// URIs specifies the URI subject alternative names of the certificate
+	URIs []string `json:"uris,omitempty"`
+	// EmailAddresses specifies the email subject alternative names of the certificate
+	EmailAddresses []string `json:"emailAddresses,omitempty"`
+	// Subject specifies the distinguished name fields of the certificate
+	Subject *X509Subject `json:"subject,omitempty"`
+	// PrivateKey specifies how the private key of the certificate is generated
+	PrivateKey *CertificatePrivateKey `json:"privateKey,omitempty"`
+	// Usages lists the key usages and extended key usages of the certificate, defaults to
+	// digital signature, key encipherment for RSA keys, and server auth for non-CA certificates
+	// +optional
+	Usages []KeyUsage `json:"usages,omitempty"`
+	// IsCA marks the certificate as a CA certificate able to sign other certificates
+	// +optional
+	IsCA bool `json:"isCA,omitempty"`
+	// MaxPathLen is the maximum number of intermediate CAs allowed below a CA certificate,
+	// 0 by default so that the CA only signs leaf certificates
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxPathLen *int32 `json:"maxPathLen,omitempty"`
+	// PermittedDNSDomains restricts the DNS names a CA certificate is allowed to sign
+	// certificates for to these domains and their subdomains
+	// +optional
+	PermittedDNSDomains []string `json:"permittedDNSDomains,omitempty"`
+	// ExcludedDNSDomains forbids a CA certificate to sign certificates for these domains
+	// and their subdomains
+	// +optional
+	ExcludedDNSDomains []string `json:"excludedDNSDomains,omitempty"`
 	// Validity specifies for how many days the certificate is valid
 	Validity string `json:"validity,omitempty"`
+	// Duration specifies how long the certificate is valid in the Go duration format,
+	// such as 2160h or 90m. It is mutually exclusive with validity
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore specifies how long before its expiry the certificate is renewed.
+	// When neither RenewBefore nor RenewBeforePercentage are set, the certificate is
+	// renewed once two thirds of its lifetime have elapsed
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+	// RenewBeforePercentage specifies the percentage of the certificate lifetime
+	// remaining at which the certificate is renewed
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	RenewBeforePercentage *int32 `json:"renewBeforePercentage,omitempty"`
+	// IssuerRef refers to the Issuer or ClusterIssuer signing the certificate,
+	// the certificate is self-signed when it is not set
+	IssuerRef *IssuerReference `json:"issuerRef,omitempty"`
+	// Keystores configures additional keystores written to the secret of the certificate
+	// +optional
+	Keystores *CertificateKeystores `json:"keystores,omitempty"`
+	// SecretTemplate customizes the secret in which the certificate is stored
+	// +optional
+	SecretTemplate *CertificateSecretTemplate `json:"secretTemplate,omitempty"`
+	// SecretDeletionPolicy controls whether the secret is deleted with the certificate, defaults to Delete.
+	// With Retain, the secret is orphaned when the certificate is deleted and can be adopted by a later
+	// certificate referencing it
+	// +optional
+	SecretDeletionPolicy SecretDeletionPolicy `json:"secretDeletionPolicy,omitempty"`
 	// SecretRef refers to the secret in which the certificate is stored
 	SecretRef SecretReference `json:"secretRef,omitempty"`
 }
 
+// SecretDeletionPolicy defines what happens to the secret when the certificate is deleted
+// +kubebuilder:validation:Enum=Delete;Retain
+type SecretDeletionPolicy string
+
+const (
+	DeleteSecretDeletionPolicy SecretDeletionPolicy = "Delete"
+	RetainSecretDeletionPolicy SecretDeletionPolicy = "Retain"
+)
+
+// CertificateSecretTemplate customizes the secret in which the certificate is stored
+// +kubebuilder:object:generate=true
+type CertificateSecretTemplate struct {
+	// Keys overrides the names of the secret keys holding the certificate, its private key
+	// and the CA certificate. Secrets with custom certificate or private key names are
+	// of type Opaque instead of kubernetes.io/tls
+	// +optional
+	Keys *CertificateSecretKeys `json:"keys,omitempty"`
+	// AdditionalOutputFormats writes the certificate and its private key to the secret
+	// in additional formats
+	// +optional
+	AdditionalOutputFormats []CertificateAdditionalOutputFormat `json:"additionalOutputFormats,omitempty"`
+	// Labels are added to the secret and kept in sync with the template
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the secret and kept in sync with the template
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// CertificateSecretKeys are the names of the secret keys holding the certificate material
+// +kubebuilder:object:generate=true
+type CertificateSecretKeys struct {
+	// Certificate is the key holding the certificate chain, defaults to tls.crt
+	// +optional
+	Certificate string `json:"certificate,omitempty"`
+	// PrivateKey is the key holding the private key, defaults to tls.key
+	// +optional
+	PrivateKey string `json:"privateKey,omitempty"`
+	// CA is the key holding the CA certificate, defaults to ca.crt
+	// +optional
+	CA string `json:"ca,omitempty"`
+}
+
+// CertificateAdditionalOutputFormat is an additional format of the certificate material
+// +kubebuilder:object:generate=true
+type CertificateAdditionalOutputFormat struct {
+	// Type of the output format. CombinedPEM writes the private key followed by the
+	// certificate chain to tls-combined.pem, DER writes the private key in binary form to key.der
+	Type CertificateOutputFormatType `json:"type"`
+}
+
+// CertificateOutputFormatType is the type of an additional output format
+// +kubebuilder:validation:Enum=CombinedPEM;DER
+type CertificateOutputFormatType string
+
+const (
+	CombinedPEMOutputFormat CertificateOutputFormatType = "CombinedPEM"
+	DEROutputFormat         CertificateOutputFormatType = "DER"
+)
+
+// CertificateKeystores configures the keystores written to the secret next to tls.crt and tls.key
+// +kubebuilder:object:generate=true
+type CertificateKeystores struct {
+	// PKCS12 writes the certificate and its key to keystore.p12, and the CA to truststore.p12
+	// when the certificate is signed by a CA
+	// +optional
+	PKCS12 *PKCS12Keystore `json:"pkcs12,omitempty"`
+	// JKS writes the certificate and its key to keystore.jks, and the CA to truststore.jks
+	// when the certificate is signed by a CA
+	// +optional
+	JKS *JKSKeystore `json:"jks,omitempty"`
+}
+
+// PKCS12Keystore configures the PKCS#12 keystore of the certificate
+// +kubebuilder:object:generate=true
+type PKCS12Keystore struct {
+	// Create enables the PKCS#12 keystore
+	Create bool `json:"create"`
+	// PasswordSecretRef refers to the key of a secret holding the password of the keystore
+	PasswordSecretRef SecretKeySelector `json:"passwordSecretRef"`
+	// Profile selects the encryption algorithms of the keystore, defaults to Modern2023.
+	// The legacy profiles are only meant for software that cannot read modern keystores
+	// +kubebuilder:validation:Enum=LegacyRC2;LegacyDES;Modern2023
+	// +optional
+	Profile PKCS12Profile `json:"profile,omitempty"`
+}
+
+// PKCS12Profile is the set of algorithms used to encrypt a PKCS#12 keystore
+type PKCS12Profile string
+
+const (
+	LegacyRC2PKCS12Profile  PKCS12Profile = "LegacyRC2"
+	LegacyDESPKCS12Profile  PKCS12Profile = "LegacyDES"
+	Modern2023PKCS12Profile PKCS12Profile = "Modern2023"
+)
+
+// JKSKeystore configures the JKS keystore of the certificate
+// +kubebuilder:object:generate=true
+type JKSKeystore struct {
+	// Create enables the JKS keystore
+	Create bool `json:"create"`
+	// PasswordSecretRef refers to the key of a secret holding the password of the keystore,
+	// which also protects the private key
+	PasswordSecretRef SecretKeySelector `json:"passwordSecretRef"`
+}
+
+// X509Subject defines the distinguished name fields set in the certificate subject
+// +kubebuilder:object:generate=true
+type X509Subject struct {
+	// CommonName of the certificate, it must be one of the subject alternative names
+	CommonName string `json:"commonName,omitempty"`
+	// Organizations to be used on the certificate
+	Organizations []string `json:"organizations,omitempty"`
+	// OrganizationalUnits to be used on the certificate
+	OrganizationalUnits []string `json:"organizationalUnits,omitempty"`
+	// Countries to be used on the certificate
+	Countries []string `json:"countries,omitempty"`
+	// Localities to be used on the certificate
+	Localities []string `json:"localities,omitempty"`
+	// Provinces to be used on the certificate
+	Provinces []string `json:"provinces,omitempty"`
+	// StreetAddresses to be used on the certificate
+	StreetAddresses []string `json:"streetAddresses,omitempty"`
+	// PostalCodes to be used on the certificate
+	PostalCodes []string `json:"postalCodes,omitempty"`
+	// SerialNumber to be used on the certificate subject
+	SerialNumber string `json:"serialNumber,omitempty"`
+}
+
+// PrivateKeyAlgorithm is the algorithm used to generate the private key
+// +kubebuilder:validation:Enum=RSA;ECDSA;Ed25519
+type PrivateKeyAlgorithm string
+
+const (
+	RSAKeyAlgorithm     PrivateKeyAlgorithm = "RSA"
+	ECDSAKeyAlgorithm   PrivateKeyAlgorithm = "ECDSA"
+	Ed25519KeyAlgorithm PrivateKeyAlgorithm = "Ed25519"
+)
+
+// PrivateKeyEncoding is the format used to encode the private key in the secret
+// +kubebuilder:validation:Enum=PKCS1;PKCS8
+type PrivateKeyEncoding string
+
+const (
+	PKCS1KeyEncoding PrivateKeyEncoding = "PKCS1"
+	PKCS8KeyEncoding PrivateKeyEncoding = "PKCS8"
+)
+
+// PrivateKeyRotationPolicy defines whether the private key is rotated when the certificate is reissued
+// +kubebuilder:validation:Enum=Always;Never
+type PrivateKeyRotationPolicy string
+
+const (
+	AlwaysRotationPolicy PrivateKeyRotationPolicy = "Always"
+	NeverRotationPolicy  PrivateKeyRotationPolicy = "Never"
+)
+
+// KeyUsage is a key usage or an extended key usage of the certificate
+// +kubebuilder:validation:Enum="signing";"digital signature";"content commitment";"key encipherment";"key agreement";"data encipherment";"cert sign";"crl sign";"encipher only";"decipher only";"any";"server auth";"client auth";"code signing";"email protection";"s/mime";"ipsec end system";"ipsec tunnel";"ipsec user";"timestamping";"ocsp signing";"microsoft sgc";"netscape sgc"
+type KeyUsage string
+
+const (
+	UsageSigning           KeyUsage = "signing"
+	UsageDigitalSignature  KeyUsage = "digital signature"
+	UsageContentCommitment KeyUsage = "content commitment"
+	UsageKeyEncipherment   KeyUsage = "key encipherment"
+	UsageKeyAgreement      KeyUsage = "key agreement"
+	UsageDataEncipherment  KeyUsage = "data encipherment"
+	UsageCertSign          KeyUsage = "cert sign"
+	UsageCRLSign           KeyUsage = "crl sign"
+	UsageEncipherOnly      KeyUsage = "encipher only"
+	UsageDecipherOnly      KeyUsage = "decipher only"
+	UsageAny               KeyUsage = "any"
+	UsageServerAuth        KeyUsage = "server auth"
+	UsageClientAuth        KeyUsage = "client auth"
+	UsageCodeSigning       KeyUsage = "code signing"
+	UsageEmailProtection   KeyUsage = "email protection"
+	UsageSMIME             KeyUsage = "s/mime"
+	UsageIPsecEndSystem    KeyUsage = "ipsec end system"
+	UsageIPsecTunnel       KeyUsage = "ipsec tunnel"
+	UsageIPsecUser         KeyUsage = "ipsec user"
+	UsageTimestamping      KeyUsage = "timestamping"
+	UsageOCSPSigning       KeyUsage = "ocsp signing"
+	UsageMicrosoftSGC      KeyUsage = "microsoft sgc"
+	UsageNetscapeSGC       KeyUsage = "netscape sgc"
+)
+
+// CertificatePrivateKey defines how the private key of the certificate is generated
+// +kubebuilder:object:generate=true
+type CertificatePrivateKey struct {
+	// Algorithm of the private key, defaults to RSA
+	Algorithm PrivateKeyAlgorithm `json:"algorithm,omitempty"`
+	// Size of the private key in bits, 2048, 3072 or 4096 for RSA and 256 or 384 for ECDSA.
+	// It is ignored for Ed25519 keys
+	Size int `json:"size,omitempty"`
+	// Encoding of the private key stored in the secret, defaults to PKCS1.
+	// PKCS1 stores ECDSA keys in the SEC 1 format and is not supported for Ed25519 keys
+	Encoding PrivateKeyEncoding `json:"encoding,omitempty"`
+	// RotationPolicy controls whether a new private key is generated when the certificate
+	// is reissued, defaults to Always. With Never, the key stored in the secret is reused
+	// as long as it matches the requested algorithm and size
+	RotationPolicy PrivateKeyRotationPolicy `json:"rotationPolicy,omitempty"`
+}
+
+// IssuerReference refers to an Issuer or a ClusterIssuer
+// +kubebuilder:object:generate=true
+type IssuerReference struct {
+	// Name of the issuer
+	Name string `json:"name"`
+	// Kind of the issuer, Issuer or ClusterIssuer, defaults to Issuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	Kind string `json:"kind,omitempty"`
+}
+
+const (
+	IssuerKind        = "Issuer"
+	ClusterIssuerKind = "ClusterIssuer"
+)
+
 // +kubebuilder:object:generate=true
 type SecretReference struct {
 	// Name of the secret
 	Name string `json:"name"`
 }
 
+// SecretKeySelector refers to a key of a secret in the namespace of the certificate
+// +kubebuilder:object:generate=true
+type SecretKeySelector struct {
+	// Name of the secret
+	Name string `json:"name"`
+	// Key of the secret holding the value
+	Key string `json:"key"`
+}
+
+// PreviousSecret is a secret the certificate was stored in before its secretRef was renamed
+// +kubebuilder:object:generate=true
+type PreviousSecret struct {
+	// Name of the previous secret
+	Name string `json:"name"`
+	// DeletionTime is the time at which the previous secret is deleted
+	DeletionTime metav1.Time `json:"deletionTime"`
+}
+
 // CertificateStatus defines the observed state of Certificate
-type CertificateStatus struct{}
+type CertificateStatus struct {
+	// Conditions describe the current state of the certificate
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// NotBefore is the time from which the issued certificate is valid
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+	// NotAfter is the time at which the issued certificate expires
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+	// RenewalTime is the time at which the certificate will be renewed
+	RenewalTime *metav1.Time `json:"renewalTime,omitempty"`
+	// SerialNumber of the issued certificate in hexadecimal
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// FingerprintSHA256 is the SHA-256 fingerprint of the issued certificate
+	FingerprintSHA256 string `json:"fingerprintSHA256,omitempty"`
+	// Revision is incremented every time a new certificate is issued
+	Revision int `json:"revision,omitempty"`
+	// ObservedGeneration is the generation of the Certificate the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// SecretName is the name of the secret holding the issued certificate
+	SecretName string `json:"secretName,omitempty"`
+	// PreviousSecrets are the secrets the certificate was stored in before its secretRef was renamed,
+	// each of them is kept until its own deletion time
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	PreviousSecrets []PreviousSecret `json:"previousSecrets,omitempty"`
+	// FailedIssuanceAttempts is the number of consecutive failed attempts to issue the certificate,
+	// it is reset once the certificate has been issued
+	FailedIssuanceAttempts int32 `json:"failedIssuanceAttempts,omitempty"`
+	// LastFailureTime is the time of the last failed attempt to issue the certificate
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+}
+
+const (
+	// CertificateConditionReady indicates that the secret holds a valid certificate matching the spec
+	CertificateConditionReady = "Ready"
+	// CertificateConditionIssuing is set to False with the last error while the certificate fails to be
+	// issued, it is removed once the certificate has been issued
+	CertificateConditionIssuing = "Issuing"
+)
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
-// +kubebuilder:printcolumn:name="Domain",type=string,JSONPath=`.spec.dnsName`,description="Domain Name registered in the certificate"
+// +kubebuilder:printcolumn:name="Domains",type=string,JSONPath=`.spec.dnsNames`,description="Domain Names registered in the certificate"
 // +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.spec.secretRef.name`,description="Name of the secret associated with the certificate"
 // +kubebuilder:printcolumn:name="Validity",type=string,JSONPath=`.spec.validity`,description="Duration of the validity of the certificate"
+// +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=`.spec.duration`,description="Duration of the certificate when set instead of its validity"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the certificate is ready"
+// +kubebuilder:printcolumn:name="Expiry",type=date,JSONPath=`.status.notAfter`,description="Time at which the certificate expires"
 
 // Certificate is the Schema for the certificates API
 type Certificate struct {
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 IsmailAbdelkefi.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// IssuerSpec defines how the certificates referencing the issuer are signed,
+// exactly one issuer type must be set
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="[has(self.selfSigned), has(self.ca), has(self.rootCA)].filter(x, x).size() == 1",message="exactly one issuer type must be set"
+type IssuerSpec struct {
+	// SelfSigned issues certificates signed by their own private key
+	SelfSigned *SelfSignedIssuer `json:"selfSigned,omitempty"`
+	// CA issues certificates signed by a CA keypair stored in a secret
+	CA *CAIssuer `json:"ca,omitempty"`
+	// RootCA issues certificates signed by a root CA keypair that the issuer generates
+	// and stores in a secret the first time it is reconciled
+	RootCA *RootCAIssuer `json:"rootCA,omitempty"`
+}
+
+// SelfSignedIssuer issues self-signed certificates
+// +kubebuilder:object:generate=true
+type SelfSignedIssuer struct{}
+
+// CAIssuer issues certificates signed by a CA keypair
+// +kubebuilder:object:generate=true
+type CAIssuer struct {
+	// SecretName is the name of the secret holding the CA certificate and private key
+	// in its tls.crt and tls.key fields. The secret of a ClusterIssuer is looked up in
+	// the cluster resource namespace of the controller
+	SecretName string `json:"secretName"`
+}
+
+// RootCAIssuer issues certificates signed by a self-generated root CA keypair
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="!(has(self.validity) && has(self.duration))",message="validity and duration are mutually exclusive"
+type RootCAIssuer struct {
+	// SecretName is the name of the secret the CA certificate and private key are stored in.
+	// The keypair is only generated when the secret does not exist, deleting the secret
+	// rotates the CA. The secret of a ClusterIssuer is stored in the cluster resource
+	// namespace of the controller
+	SecretName string `json:"secretName"`
+	// Validity specifies for how many days the CA certificate is valid, 3650d by default
+	// +kubebuilder:validation:Pattern=`^[1-9][0-9]*d$`
+	// +optional
+	Validity string `json:"validity,omitempty"`
+	// Duration specifies how long the CA certificate is valid in the Go duration format,
+	// such as 87600h. It is mutually exclusive with validity
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// Subject specifies the distinguished name fields of the CA certificate,
+	// the common name defaults to the name of the issuer
+	// +optional
+	Subject *X509Subject `json:"subject,omitempty"`
+	// PrivateKey specifies how the private key of the CA is generated
+	// +optional
+	PrivateKey *CertificatePrivateKey `json:"privateKey,omitempty"`
+	// MaxPathLen is the maximum number of intermediate CAs allowed below the root CA,
+	// 0 by default so that the CA only signs leaf certificates
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxPathLen *int32 `json:"maxPathLen,omitempty"`
+}
+
+// IssuerStatus defines the observed state of an Issuer or ClusterIssuer
+// +kubebuilder:object:generate=true
+type IssuerStatus struct {
+	// Conditions describe the current state of the issuer
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// IssuerConditionReady indicates that the issuer is able to sign certificates
+	IssuerConditionReady = "Ready"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the issuer is ready"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Issuer is the Schema for the issuers API, it signs certificates of its own namespace
+type Issuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IssuerSpec   `json:"spec,omitempty"`
+	Status IssuerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// IssuerList contains a list of Issuer
+type IssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Issuer `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the issuer is ready"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterIssuer is the Schema for the clusterissuers API, it signs certificates of every namespace
+type ClusterIssuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IssuerSpec   `json:"spec,omitempty"`
+	Status IssuerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:object:generate=true
+// ClusterIssuerList contains a list of ClusterIssuer
+type ClusterIssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterIssuer `json:"items"`
+}
+
+// GenericIssuer is implemented by both Issuer and ClusterIssuer
+// +kubebuilder:object:generate=false
+type GenericIssuer interface {
+	runtime.Object
+	metav1.Object
+	GetSpec() *IssuerSpec
+	GetStatus() *IssuerStatus
+}
+
+var _ GenericIssuer = &Issuer{}
+var _ GenericIssuer = &ClusterIssuer{}
+
+func (i *Issuer) GetSpec() *IssuerSpec {
+	return &i.Spec
+}
+
+func (i *Issuer) GetStatus() *IssuerStatus {
+	return &i.Status
+}
+
+func (c *ClusterIssuer) GetSpec() *IssuerSpec {
+	return &c.Spec
+}
+
+func (c *ClusterIssuer) GetStatus() *IssuerStatus {
+	return &c.Status
+}
+
+func init() {
+	SchemeBuilder.Register(&Issuer{}, &IssuerList{}, &ClusterIssuer{}, &ClusterIssuerList{})
+}
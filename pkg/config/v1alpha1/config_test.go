@@ -0,0 +1,142 @@
+package v1alpha1
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguration(t *testing.T) {
+	writeConfig := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("should default an empty configuration", func(t *testing.T) {
+		cfg, err := Load("")
+		require.NoError(t, err)
+		assert.Empty(t, Validate(cfg))
+
+		assert.Equal(t, "certaur-system", cfg.ClusterResourceNamespace)
+		assert.Equal(t, "365d", cfg.Defaults.Validity)
+		assert.Equal(t, PrivateKeyDefaults{Algorithm: certsv1.RSAKeyAlgorithm, Size: 2048}, cfg.Defaults.PrivateKey)
+		assert.Equal(t, certsv1.DeleteSecretDeletionPolicy, cfg.Defaults.SecretDeletionPolicy)
+		assert.Equal(t, DefaultMinDuration, cfg.Webhook.MinDuration.Duration)
+		assert.Equal(t, DefaultMaxDuration, cfg.Webhook.MaxDuration.Duration)
+		assert.Equal(t, 1, cfg.Controller.MaxConcurrentReconciles)
+		assert.Equal(t, time.Minute, cfg.Controller.Backdate.Duration)
+		assert.Equal(t, DefaultSecretRenameGracePeriod, cfg.Controller.SecretRenameGracePeriod.Duration)
+		assert.Equal(t, time.Hour, cfg.Controller.MaxIssuanceBackoff.Duration)
+		assert.Nil(t, cfg.Renewal.RenewBeforePercentage)
+		assert.True(t, cfg.FeatureGates.Enabled(KeystoresFeature))
+		assert.True(t, cfg.FeatureGates.Enabled(SecretAdoptionFeature))
+	})
+
+	t.Run("should load and default a configuration file", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, `
+apiVersion: config.certs.k8c.io/v1alpha1
+kind: CertaurConfiguration
+defaults:
+  validity: 90d
+  privateKey:
+    algorithm: ECDSA
+webhook:
+  maxDuration: 2160h
+controller:
+  maxConcurrentReconciles: 4
+  backdate: 0s
+  secretRenameGracePeriod: 0s
+renewal:
+  renewBeforePercentage: 25
+featureGates:
+  Keystores: false
+`))
+		require.NoError(t, err)
+		assert.Empty(t, Validate(cfg))
+
+		assert.Equal(t, "90d", cfg.Defaults.Validity)
+		assert.Equal(t, PrivateKeyDefaults{Algorithm: certsv1.ECDSAKeyAlgorithm, Size: 256}, cfg.Defaults.PrivateKey)
+		assert.Equal(t, 90*24*time.Hour, cfg.Webhook.MaxDuration.Duration)
+		assert.Equal(t, DefaultMinDuration, cfg.Webhook.MinDuration.Duration)
+		assert.Equal(t, 4, cfg.Controller.MaxConcurrentReconciles)
+		assert.Equal(t, time.Duration(0), cfg.Controller.Backdate.Duration)
+		assert.Equal(t, time.Duration(0), cfg.Controller.SecretRenameGracePeriod.Duration)
+		assert.Equal(t, int32(25), *cfg.Renewal.RenewBeforePercentage)
+		assert.False(t, cfg.FeatureGates.Enabled(KeystoresFeature))
+		assert.True(t, cfg.FeatureGates.Enabled(SecretAdoptionFeature))
+	})
+
+	t.Run("should reject unknown fields and versions", func(t *testing.T) {
+		_, err := Load(writeConfig(t, `
+apiVersion: config.certs.k8c.io/v1alpha1
+kind: CertaurConfiguration
+defaults:
+  lifetime: 90d
+`))
+		assert.ErrorContains(t, err, "unknown field")
+
+		_, err = Load(writeConfig(t, `
+apiVersion: config.certs.k8c.io/v1beta1
+kind: CertaurConfiguration
+`))
+		assert.ErrorContains(t, err, "unsupported configuration")
+
+		_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "failed to read configuration file")
+	})
+
+	t.Run("should reject invalid configurations", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, `
+apiVersion: config.certs.k8c.io/v1alpha1
+kind: CertaurConfiguration
+clusterResourceNamespace: Certaur_System
+defaults:
+  validity: 3650d
+  privateKey:
+    algorithm: RSA
+    size: 1024
+  secretDeletionPolicy: Orphan
+webhook:
+  minDuration: 48h
+  maxDuration: 24h
+controller:
+  maxConcurrentReconciles: -1
+  secretRenameGracePeriod: -1h
+  maxIssuanceBackoff: -5m
+renewal:
+  renewBeforePercentage: 100
+featureGates:
+  Unknown: true
+`))
+		require.NoError(t, err)
+
+		errs := Validate(cfg).ToAggregate().Error()
+		for _, msg := range []string{
+			"clusterResourceNamespace: Invalid value",
+			"defaults.validity: Invalid value: \"3650d\"",
+			"defaults.privateKey.size: Unsupported value: 1024",
+			"defaults.secretDeletionPolicy: Unsupported value: \"Orphan\"",
+			"webhook.maxDuration: Invalid value: \"24h0m0s\": must not be shorter than minDuration",
+			"controller.maxConcurrentReconciles: Invalid value: -1",
+			"controller.secretRenameGracePeriod: Invalid value",
+			"controller.maxIssuanceBackoff: Invalid value",
+			"renewal.renewBeforePercentage: Invalid value: 100",
+			"featureGates[Unknown]: Unsupported value: \"Unknown\"",
+		} {
+			assert.True(t, strings.Contains(errs, msg), "missing %q in %s", msg, errs)
+		}
+
+		// A validity without the days suffix would be defaulted on certificates and rejected by the webhook
+		cfg = New()
+		cfg.Defaults.Validity = "365"
+		errs = Validate(cfg).ToAggregate().Error()
+		assert.Contains(t, errs, "defaults.validity: Invalid value: \"365\": must be a positive integer followed by 'd'")
+	})
+}
@@ -0,0 +1,88 @@
+package v1alpha1
+
+import (
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	issuerutil "github.com/AKI-25/certaur/pkg/util/issuer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultValidity is the lifetime of certificates without validity nor duration by default
+	DefaultValidity = "365d"
+	// DefaultMinDuration is the shortest lifetime accepted for certificates by default
+	DefaultMinDuration = 5 * time.Minute
+	// DefaultMaxDuration is the longest lifetime accepted for certificates by default
+	DefaultMaxDuration = 1825 * 24 * time.Hour
+	// DefaultMaxConcurrentReconciles is the number of certificates reconciled concurrently by default
+	DefaultMaxConcurrentReconciles = 1
+	// DefaultSecretRenameGracePeriod is how long the previous secret of a certificate is kept by default
+	// after its secretRef has been renamed
+	DefaultSecretRenameGracePeriod = 24 * time.Hour
+	// DefaultMaxIssuanceBackoff caps the backoff between failed attempts to issue a certificate by default
+	DefaultMaxIssuanceBackoff = time.Hour
+)
+
+// New returns the default configuration
+func New() *CertaurConfiguration {
+	cfg := &CertaurConfiguration{}
+	SetDefaults(cfg)
+	return cfg
+}
+
+// SetDefaults sets the default values of the fields missing from the configuration
+func SetDefaults(cfg *CertaurConfiguration) {
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = GroupVersion.String()
+	}
+	if cfg.Kind == "" {
+		cfg.Kind = Kind
+	}
+	if cfg.ClusterResourceNamespace == "" {
+		cfg.ClusterResourceNamespace = issuerutil.DefaultClusterResourceNamespace
+	}
+
+	defaults := &cfg.Defaults
+	if defaults.Validity == "" {
+		defaults.Validity = DefaultValidity
+	}
+	defaults.PrivateKey.Algorithm, defaults.PrivateKey.Size = certificateutil.KeyAlgorithm(&certsv1.CertificatePrivateKey{
+		Algorithm: defaults.PrivateKey.Algorithm,
+		Size:      defaults.PrivateKey.Size,
+	})
+	if defaults.SecretDeletionPolicy == "" {
+		defaults.SecretDeletionPolicy = certsv1.DeleteSecretDeletionPolicy
+	}
+
+	if cfg.Webhook.MinDuration.Duration == 0 {
+		cfg.Webhook.MinDuration.Duration = DefaultMinDuration
+	}
+	if cfg.Webhook.MaxDuration.Duration == 0 {
+		cfg.Webhook.MaxDuration.Duration = DefaultMaxDuration
+	}
+
+	if cfg.Controller.MaxConcurrentReconciles == 0 {
+		cfg.Controller.MaxConcurrentReconciles = DefaultMaxConcurrentReconciles
+	}
+	// a zero backdate or grace period is valid, only missing ones are defaulted
+	if cfg.Controller.Backdate == nil {
+		cfg.Controller.Backdate = &metav1.Duration{Duration: certificateutil.DefaultBackdate}
+	}
+	if cfg.Controller.SecretRenameGracePeriod == nil {
+		cfg.Controller.SecretRenameGracePeriod = &metav1.Duration{Duration: DefaultSecretRenameGracePeriod}
+	}
+	if cfg.Controller.MaxIssuanceBackoff.Duration == 0 {
+		cfg.Controller.MaxIssuanceBackoff.Duration = DefaultMaxIssuanceBackoff
+	}
+
+	if cfg.FeatureGates == nil {
+		cfg.FeatureGates = FeatureGates{}
+	}
+	for feature, enabled := range defaultFeatureGates {
+		if _, exists := cfg.FeatureGates[feature]; !exists {
+			cfg.FeatureGates[feature] = enabled
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Load reads and defaults the configuration file at path, the default configuration is returned
+// when path is empty. Unknown fields are rejected, the configuration is not validated
+func Load(path string) (*CertaurConfiguration, error) {
+	if path == "" {
+		return New(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	cfg := &CertaurConfiguration{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration file %s: %w", path, err)
+	}
+	if cfg.APIVersion != GroupVersion.String() || cfg.Kind != Kind {
+		return nil, fmt.Errorf("unsupported configuration %s %s, expected %s %s", cfg.APIVersion, cfg.Kind, GroupVersion, Kind)
+	}
+
+	SetDefaults(cfg)
+	return cfg, nil
+}
@@ -0,0 +1,117 @@
+// Package v1alpha1 contains the v1alpha1 version of the configuration file of the certaur controller manager
+package v1alpha1
+
+import (
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// GroupVersion is the group version of the configuration file
+	GroupVersion = schema.GroupVersion{Group: "config.certs.k8c.io", Version: "v1alpha1"}
+)
+
+// Kind is the kind of the configuration file
+const Kind = "CertaurConfiguration"
+
+// CertaurConfiguration configures the certaur controller manager, it is loaded from the file passed with --config
+type CertaurConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ClusterResourceNamespace is the namespace holding the CA secrets referenced by ClusterIssuers
+	ClusterResourceNamespace string `json:"clusterResourceNamespace,omitempty"`
+
+	// Defaults are set by the webhook on the certificates that do not specify them
+	Defaults CertificateDefaults `json:"defaults,omitempty"`
+
+	// Webhook configures the validation of certificates
+	Webhook WebhookConfiguration `json:"webhook,omitempty"`
+
+	// Controller configures the reconciliation of certificates
+	Controller ControllerConfiguration `json:"controller,omitempty"`
+
+	// Renewal configures when certificates are renewed
+	Renewal RenewalConfiguration `json:"renewal,omitempty"`
+
+	// FeatureGates enables or disables features by name, see the Feature constants
+	FeatureGates FeatureGates `json:"featureGates,omitempty"`
+}
+
+// CertificateDefaults are the values set on certificates that do not specify them
+type CertificateDefaults struct {
+	// Validity is the lifetime in days of certificates without validity nor duration
+	Validity string `json:"validity,omitempty"`
+
+	// PrivateKey is the algorithm and size of the private key of certificates that specify neither
+	PrivateKey PrivateKeyDefaults `json:"privateKey,omitempty"`
+
+	// SecretDeletionPolicy is the secret deletion policy of certificates without one
+	SecretDeletionPolicy certsv1.SecretDeletionPolicy `json:"secretDeletionPolicy,omitempty"`
+}
+
+// PrivateKeyDefaults is the algorithm and size of the default private key
+type PrivateKeyDefaults struct {
+	Algorithm certsv1.PrivateKeyAlgorithm `json:"algorithm,omitempty"`
+	Size      int                         `json:"size,omitempty"`
+}
+
+// WebhookConfiguration bounds the lifetime of certificates accepted by the webhook
+type WebhookConfiguration struct {
+	// MinDuration is the shortest certificate lifetime accepted by the webhook
+	MinDuration metav1.Duration `json:"minDuration,omitempty"`
+
+	// MaxDuration is the longest certificate lifetime accepted by the webhook
+	MaxDuration metav1.Duration `json:"maxDuration,omitempty"`
+}
+
+// ControllerConfiguration configures the certificate controller
+type ControllerConfiguration struct {
+	// MaxConcurrentReconciles is the number of certificates reconciled concurrently
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+
+	// Backdate is how long the NotBefore of issued certificates and generated root CAs is set in the
+	// past to tolerate clock skew
+	Backdate *metav1.Duration `json:"backdate,omitempty"`
+
+	// SecretRenameGracePeriod is how long the previous secret of a certificate is kept after its
+	// secretRef has been renamed, 0 deletes it right away
+	SecretRenameGracePeriod *metav1.Duration `json:"secretRenameGracePeriod,omitempty"`
+
+	// MaxIssuanceBackoff caps the exponential backoff between failed attempts to issue a certificate
+	MaxIssuanceBackoff metav1.Duration `json:"maxIssuanceBackoff,omitempty"`
+}
+
+// RenewalConfiguration configures the renewal of certificates
+type RenewalConfiguration struct {
+	// RenewBeforePercentage is set by the webhook on certificates setting neither renewBefore nor
+	// renewBeforePercentage. Certificates are renewed after two thirds of their lifetime when it is unset
+	RenewBeforePercentage *int32 `json:"renewBeforePercentage,omitempty"`
+}
+
+// Feature is the name of a feature that can be enabled or disabled with the feature gates
+type Feature string
+
+const (
+	// KeystoresFeature allows certificates to request PKCS#12 and JKS keystores
+	KeystoresFeature Feature = "Keystores"
+	// SecretAdoptionFeature allows certificates to adopt existing secrets
+	SecretAdoptionFeature Feature = "SecretAdoption"
+)
+
+// defaultFeatureGates lists the known features along with whether they are enabled by default
+var defaultFeatureGates = map[Feature]bool{
+	KeystoresFeature:      true,
+	SecretAdoptionFeature: true,
+}
+
+// FeatureGates enables or disables features by name
+type FeatureGates map[Feature]bool
+
+// Enabled reports whether the feature is enabled, falling back to its default when it is not set
+func (f FeatureGates) Enabled(feature Feature) bool {
+	if enabled, exists := f[feature]; exists {
+		return enabled
+	}
+	return defaultFeatureGates[feature]
+}
@@ -0,0 +1,115 @@
+package v1alpha1
+
+import (
+	"slices"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Validate checks a defaulted configuration
+func Validate(cfg *CertaurConfiguration) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if cfg.APIVersion != GroupVersion.String() {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("apiVersion"), cfg.APIVersion, []string{GroupVersion.String()}))
+	}
+	if cfg.Kind != Kind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), cfg.Kind, []string{Kind}))
+	}
+	for _, msg := range validation.IsDNS1123Label(cfg.ClusterResourceNamespace) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("clusterResourceNamespace"), cfg.ClusterResourceNamespace, msg))
+	}
+
+	allErrs = append(allErrs, validateWebhook(&cfg.Webhook, field.NewPath("webhook"))...)
+	allErrs = append(allErrs, validateDefaults(cfg, field.NewPath("defaults"))...)
+	allErrs = append(allErrs, validateController(&cfg.Controller, field.NewPath("controller"))...)
+
+	if p := cfg.Renewal.RenewBeforePercentage; p != nil && (*p < 1 || *p > 99) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("renewal").Child("renewBeforePercentage"), *p, "must be between 1 and 99"))
+	}
+	for feature := range cfg.FeatureGates {
+		if _, known := defaultFeatureGates[feature]; !known {
+			allErrs = append(allErrs, field.NotSupported(field.NewPath("featureGates").Key(string(feature)), feature, knownFeatures()))
+		}
+	}
+	return allErrs
+}
+
+func validateWebhook(webhook *WebhookConfiguration, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if webhook.MinDuration.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("minDuration"), webhook.MinDuration.Duration.String(), "must be positive"))
+	}
+	if webhook.MaxDuration.Duration < webhook.MinDuration.Duration {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxDuration"), webhook.MaxDuration.Duration.String(), "must not be shorter than minDuration"))
+	}
+	return allErrs
+}
+
+// checks that the defaults set on certificates would be accepted by the webhook
+func validateDefaults(cfg *CertaurConfiguration, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	defaults := &cfg.Defaults
+
+	validity, err := certificateutil.ValidityDuration(defaults.Validity)
+	switch {
+	case err != nil, !certificateutil.IsValidity(defaults.Validity):
+		allErrs = append(allErrs, field.Invalid(path.Child("validity"), defaults.Validity, "must be a positive integer followed by 'd'"))
+	case validity < cfg.Webhook.MinDuration.Duration || validity > cfg.Webhook.MaxDuration.Duration:
+		allErrs = append(allErrs, field.Invalid(path.Child("validity"), defaults.Validity, "must be between the webhook minDuration and maxDuration"))
+	}
+
+	privateKeyPath := path.Child("privateKey")
+	switch key := defaults.PrivateKey; key.Algorithm {
+	case certsv1.RSAKeyAlgorithm:
+		if !slices.Contains([]int{2048, 3072, 4096}, key.Size) {
+			allErrs = append(allErrs, field.NotSupported(privateKeyPath.Child("size"), key.Size, []string{"2048", "3072", "4096"}))
+		}
+	case certsv1.ECDSAKeyAlgorithm:
+		if !slices.Contains([]int{256, 384}, key.Size) {
+			allErrs = append(allErrs, field.NotSupported(privateKeyPath.Child("size"), key.Size, []string{"256", "384"}))
+		}
+	case certsv1.Ed25519KeyAlgorithm:
+	default:
+		allErrs = append(allErrs, field.NotSupported(privateKeyPath.Child("algorithm"), key.Algorithm,
+			[]certsv1.PrivateKeyAlgorithm{certsv1.RSAKeyAlgorithm, certsv1.ECDSAKeyAlgorithm, certsv1.Ed25519KeyAlgorithm}))
+	}
+
+	switch defaults.SecretDeletionPolicy {
+	case certsv1.DeleteSecretDeletionPolicy, certsv1.RetainSecretDeletionPolicy:
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("secretDeletionPolicy"), defaults.SecretDeletionPolicy,
+			[]certsv1.SecretDeletionPolicy{certsv1.DeleteSecretDeletionPolicy, certsv1.RetainSecretDeletionPolicy}))
+	}
+	return allErrs
+}
+
+func validateController(controller *ControllerConfiguration, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if controller.MaxConcurrentReconciles < 1 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxConcurrentReconciles"), controller.MaxConcurrentReconciles, "must be at least 1"))
+	}
+	if controller.Backdate != nil && controller.Backdate.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("backdate"), controller.Backdate.Duration.String(), "must not be negative"))
+	}
+	if controller.SecretRenameGracePeriod != nil && controller.SecretRenameGracePeriod.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("secretRenameGracePeriod"), controller.SecretRenameGracePeriod.Duration.String(), "must not be negative"))
+	}
+	if controller.MaxIssuanceBackoff.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxIssuanceBackoff"), controller.MaxIssuanceBackoff.Duration.String(), "must be positive"))
+	}
+	return allErrs
+}
+
+// knownFeatures returns the sorted names of the known features
+func knownFeatures() []Feature {
+	features := make([]Feature, 0, len(defaultFeatureGates))
+	for feature := range defaultFeatureGates {
+		features = append(features, feature)
+	}
+	slices.Sort(features)
+	return features
+}
@@ -3,18 +3,30 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
 	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	issuerutil "github.com/AKI-25/certaur/pkg/util/issuer"
 	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // CertificateReconciler reconciles a Certificate object
@@ -23,9 +35,29 @@ type CertificateReconciler struct {
 	Scheme   *runtime.Scheme
 	Logger   logr.Logger
 	Recorder record.EventRecorder
+	// ClusterResourceNamespace is the namespace holding the CA secrets of ClusterIssuers
+	ClusterResourceNamespace string
+	// Backdate is how long the NotBefore of issued certificates is set in the past
+	Backdate time.Duration
+	// SecretRenameGracePeriod is how long the previous secret of a certificate is kept after its
+	// secretRef has been renamed, so that workloads mounting it can move to the new secret
+	SecretRenameGracePeriod time.Duration
+	// MaxConcurrentReconciles is the number of certificates reconciled concurrently
+	MaxConcurrentReconciles int
+	// MaxIssuanceBackoff caps the exponential backoff between failed attempts to issue a certificate
+	MaxIssuanceBackoff time.Duration
+	// SecretMetadataCache holds the metadata of every secret, it is watched for the password secrets
+	// of keystores and the CA secrets of issuers, which are missing from the cache of the manager
+	SecretMetadataCache cache.Cache
+
+	// changedDependencies holds the certificates enqueued by a change of their issuer, CA secret or
+	// password secrets, which skip their issuance backoff as the cause of the failure may be fixed
+	changedDependencies sync.Map
 }
 
 func (r *CertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_, dependencyChanged := r.changedDependencies.LoadAndDelete(req.NamespacedName)
+
 	// Fetch the Certificate instance
 	var cert certsv1.Certificate
 	if err := r.Get(ctx, req.NamespacedName, &cert); err != nil {
@@ -38,77 +70,322 @@ func (r *CertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Retain the secret of deleted certificates according to their secret deletion policy
+	if deleting, err := r.reconcileDeletionPolicy(ctx, &cert); deleting || err != nil {
+		return ctrl.Result{}, err
+	}
+
 	secretName := cert.Spec.SecretRef.Name
 
+	// Delete the secrets the certificate was stored in before its secretRef was renamed once their grace period is over
+	if err := r.reconcilePreviousSecrets(ctx, &cert); err != nil {
+		r.Logger.Error(err, "unable to delete previous secrets", "SecretNames", previousSecretNames(&cert))
+		return ctrl.Result{}, err
+	}
+
+	// Wait for the backoff of a certificate that failed to be issued to be over before trying again,
+	// unless a dependency of the certificate changed since
+	if wait := r.issuanceBackoffRemaining(&cert); wait > 0 && !dependencyChanged {
+		r.Logger.Info("Backing off certificate issuance", "CertificateName", cert.Name, "Attempts", cert.Status.FailedIssuanceAttempts, "RetryIn", wait)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	// Resolve the CA signing the certificate, nil for self-signed certificates
+	ca, err := issuerutil.ResolveCA(ctx, r.Client, &cert, r.ClusterResourceNamespace)
+	if err != nil {
+		return r.markIssuanceFailed(ctx, &cert, "IssuerNotReady", "Unable to sign certificate", err)
+	}
+	// A CA certificate must stay within the path length constraint of the CA signing it
+	if err := certificateutil.CheckCAPathLen(ca, &cert.Spec); err != nil {
+		return r.markIssuanceFailed(ctx, &cert, "IssuerPathLenExceeded", "Issuer is not allowed to sign CA certificates", err)
+	}
+
+	// Read the passwords of the keystores written next to the certificate
+	passwords, err := secretutil.KeystorePasswords(ctx, r.Client, &cert)
+	if err != nil {
+		return r.markIssuanceFailed(ctx, &cert, "KeystorePasswordUnavailable", "Unable to read keystore password", err)
+	}
+	issueOpts := certificateutil.IssueOptions{CA: ca, Backdate: r.Backdate, KeystorePasswords: passwords}
+
 	// Check if the secret already exists
 	secret := &corev1.Secret{}
 	secretNamespacedName := types.NamespacedName{Name: secretName, Namespace: req.Namespace}
-	err := r.Get(ctx, secretNamespacedName, secret)
+	err = r.Get(ctx, secretNamespacedName, secret)
 
 	// If secret doesn't exist, generate a new TLS certificate and create the secret
 	if apierrors.IsNotFound(err) {
 		// clean up orphaned Kubernetes secrets that are still owned by a Certificate Custom Resource (CR)
 		// but are no longer actively associated with it,
 		// likely due to an interruption during the reconciliation process.
-		err := secretutil.FindAndDeletePreviousSecrets(ctx, r.Client, &cert)
+		// The secret the certificate was stored in before its secretRef was renamed and the previous
+		// secrets still in their grace period are kept
+		kept, err := secretutil.FindAndDeletePreviousSecrets(ctx, r.Client, &cert, append(previousSecretNames(&cert), cert.Status.SecretName)...)
 		if err != nil {
 			r.Logger.Error(err, "failed to find and delete previous secrets")
 			return ctrl.Result{}, err
 		}
 
+		if previous := kept[cert.Status.SecretName]; previous != nil {
+			if err := r.recordPreviousSecret(ctx, &cert, previous.Name); err != nil {
+				r.Logger.Error(err, "unable to record previous secret", "SecretName", previous.Name)
+				return ctrl.Result{}, err
+			}
+			crtPEM, err := r.migrateSecret(ctx, &cert, previous, issueOpts)
+			if err != nil {
+				return r.markIssuanceFailed(ctx, &cert, "SecretMigrationFailed", fmt.Sprintf("Failed to migrate Secret %s to %s", previous.Name, secretName), err)
+			}
+			if crtPEM != nil {
+				if err := r.updateStatus(ctx, &cert, crtPEM, false); err != nil {
+					return ctrl.Result{}, err
+				}
+				return requeueAtRenewal(&cert), nil
+			}
+		}
+
 		r.Logger.Info("Secret not found, creating new secret", "SecretName", secretName)
 
 		// Generate TLS certificate
-		crtPEM, keyPEM, err := certificateutil.GenerateTLSCertificate(cert.Spec.DnsName, cert.Spec.Validity)
+		crtPEM, keyPEM, err := certificateutil.GenerateTLSCertificate(&cert.Spec, issueOpts)
 		if err != nil {
-			r.Logger.Error(err, "failed to generate TLS certificate")
-			return ctrl.Result{}, err
+			return r.markIssuanceFailed(ctx, &cert, "IssuanceFailed", "Failed to generate TLS certificate", err)
 		}
 
 		// Create a new secret
-		err = secretutil.CreateSecret(req, r.Client, ctx, &cert, secretName, crtPEM, keyPEM)
+		err = secretutil.CreateSecret(req, r.Client, ctx, &cert, secretName, crtPEM, keyPEM, issueOpts)
 		if err != nil {
-			r.RecordAndLogError(&cert, "SecretCreationFailed", fmt.Sprintf("Failed to create Secret %s: %v", cert.Spec.SecretRef.Name, err), err)
-			return ctrl.Result{}, err
+			return r.markIssuanceFailed(ctx, &cert, "SecretCreationFailed", fmt.Sprintf("Failed to create Secret %s", cert.Spec.SecretRef.Name), err)
 		}
 
 		r.RecordAndLogInfo(&cert, "SecretCreationSuccessful", fmt.Sprintf("Successfully created Secret %s", cert.Spec.SecretRef.Name))
-		return ctrl.Result{}, nil
+		if err := r.updateStatus(ctx, &cert, crtPEM, true); err != nil {
+			return ctrl.Result{}, err
+		}
+		return requeueAtRenewal(&cert), nil
 	} else if err != nil {
 		r.Logger.Error(err, "unable to fetch Secret")
 		return ctrl.Result{}, err
 	}
-	ok, err := secretutil.CheckSecretIntegrity(&cert, secret)
+
+	// Adopt a secret retained after the deletion of a previous certificate or opted in for adoption,
+	// its material is only regenerated when it does not pass the integrity check below
+	if secretutil.IsAdoptable(secret) {
+		if err := secretutil.AdoptSecret(ctx, r.Client, &cert, secret); err != nil {
+			r.RecordAndLogError(&cert, "SecretAdoptionFailed", fmt.Sprintf("Failed to adopt Secret %s: %v", secretName, err), err)
+			return ctrl.Result{}, err
+		}
+		r.RecordAndLogInfo(&cert, "SecretAdopted", fmt.Sprintf("Adopted Secret %s", secretName))
+	}
+
+	// The type of a secret cannot be changed in place, a secret owned by the certificate whose type
+	// no longer matches its key names is recreated with the new type
+	if err := secretutil.CheckSecretType(&cert, secret); err != nil {
+		if !secretutil.IsOwnerReference(&cert, secret) {
+			return r.markIssuanceFailed(ctx, &cert, "SecretTypeChanged", fmt.Sprintf("Unable to recreate Secret %s", secretName), err)
+		}
+		crtPEM, err := secretutil.RecreateSecret(ctx, r.Client, &cert, secret, issueOpts)
+		if err != nil {
+			return r.markIssuanceFailed(ctx, &cert, "SecretRecreationFailed", fmt.Sprintf("Failed to recreate Secret %s", secretName), err)
+		}
+		r.RecordAndLogInfo(&cert, "SecretRecreated", fmt.Sprintf("Recreated Secret %s as %s for its new key names", secretName, secretutil.SecretType(&cert)))
+		if err := r.updateStatus(ctx, &cert, crtPEM, true); err != nil {
+			return ctrl.Result{}, err
+		}
+		return requeueAtRenewal(&cert), nil
+	}
+	ok, err := secretutil.CheckSecretIntegrity(&cert, secret, issueOpts)
 	if err != nil {
 		r.Logger.Error(err, "unable to check secret's integrity")
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.markNotReady(ctx, &cert, "SecretIntegrityCheckFailed", err)
 	}
 	if !ok {
 		r.RecordAndLogInfo(&cert, "SecretIntegrityCheckFailed", fmt.Sprintf("Secret's integrity has been compromised: Secret %s", cert.Spec.SecretRef.Name))
-		err := secretutil.EnsureSecretIntegrity(ctx, r.Client, &cert, secret)
+		err := secretutil.EnsureSecretIntegrity(ctx, r.Client, &cert, secret, issueOpts)
 		if err != nil {
-			r.RecordAndLogError(&cert, "SecretIntegrityRestoreFailed", "unable to restore secret's integrity", err)
-			return ctrl.Result{
-				Requeue: true,
-			}, err
+			return r.markIssuanceFailed(ctx, &cert, "SecretIntegrityRestoreFailed", "Unable to restore secret's integrity", err)
 		}
 		r.RecordAndLogError(&cert, "SecretIntegrityRestored", "secret's integrity is restored", err)
+		if err := r.updateStatus(ctx, &cert, secret.Data[secretutil.SecretKeys(&cert).Certificate], true); err != nil {
+			return ctrl.Result{}, err
+		}
+		return requeueAtRenewal(&cert), nil
+	}
+
+	// Renew the certificate once its renewal time has passed
+	parsedCert, err := secretutil.ExtractCertData(&cert, secret)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	renewed := false
+	if renewalTime := certificateutil.RenewalTime(parsedCert.NotBefore, parsedCert.NotAfter, &cert.Spec); !time.Now().Before(renewalTime) {
+		r.Logger.Info("Certificate is due for renewal", "CertificateName", cert.Name, "RenewalTime", renewalTime)
+		if err := secretutil.EnsureSecretIntegrity(ctx, r.Client, &cert, secret, issueOpts); err != nil {
+			return r.markIssuanceFailed(ctx, &cert, "CertificateRenewalFailed", fmt.Sprintf("Failed to renew certificate into Secret %s", secretName), err)
+		}
+		r.RecordAndLogInfo(&cert, "CertificateRenewed", fmt.Sprintf("Successfully renewed certificate into Secret %s", secretName))
+		renewed = true
 	} else {
 		r.RecordAndLogInfo(&cert, "CertificateValid", fmt.Sprintf("Certificate %s and its corresponding secret %s are valid", cert.Name, secretName))
 		r.Logger.Info("Certificate and its corresponding secret are valid", "CertificateName", cert.Name, "SecretName", secretName)
+
+		// Keep the labels and annotations of the secret in sync with the secret template
+		synced, err := secretutil.SyncSecretTemplate(ctx, r.Client, &cert, secret)
+		if err != nil {
+			r.Logger.Error(err, "unable to sync secret template", "SecretName", secretName)
+			return ctrl.Result{}, err
+		}
+		if synced {
+			r.RecordAndLogInfo(&cert, "SecretTemplateSynced", fmt.Sprintf("Synced labels and annotations of Secret %s", secretName))
+		}
 	}
 
-	return ctrl.Result{}, nil
+	if err := r.updateStatus(ctx, &cert, secret.Data[secretutil.SecretKeys(&cert).Certificate], renewed); err != nil {
+		return ctrl.Result{}, err
+	}
+	return requeueAtRenewal(&cert), nil
+}
+
+// requeue the certificate at its renewal time so that it gets renewed before expiring,
+// or earlier to delete its previous secrets once their grace period is over
+func requeueAtRenewal(cert *certsv1.Certificate) ctrl.Result {
+	requeueAt := cert.Status.RenewalTime
+	for i := range cert.Status.PreviousSecrets {
+		if deletion := &cert.Status.PreviousSecrets[i].DeletionTime; requeueAt == nil || deletion.Before(requeueAt) {
+			requeueAt = deletion
+		}
+	}
+	if requeueAt == nil {
+		return ctrl.Result{}
+	}
+	if untilRequeue := time.Until(requeueAt.Time); untilRequeue > 0 {
+		return ctrl.Result{RequeueAfter: untilRequeue}
+	}
+	return ctrl.Result{Requeue: true}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CertificateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Index secrets by the certificates owning them to look up previous secrets without listing every secret
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Secret{}, secretutil.OwnerUIDIndex, secretutil.IndexOwnerUID); err != nil {
+		return err
+	}
+
+	// Index certificates by their password secrets to reconcile them when a keystore password changes
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &certsv1.Certificate{}, secretutil.PasswordSecretIndex, secretutil.IndexPasswordSecrets); err != nil {
+		return err
+	}
+
+	// Index certificates by their issuer to reconcile them when the CA of the issuer changes
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &certsv1.Certificate{}, issuerutil.IssuerRefIndex, issuerutil.IndexIssuerRef); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&certsv1.Certificate{}).
 		Owns(&corev1.Secret{}).
+		WatchesRawSource(source.Kind(r.SecretMetadataCache, client.Object(secretutil.SecretMetadata()), handler.EnqueueRequestsFromMapFunc(r.certificatesForPasswordSecret))).
+		WatchesRawSource(source.Kind(r.SecretMetadataCache, client.Object(secretutil.SecretMetadata()), handler.EnqueueRequestsFromMapFunc(r.certificatesForCASecret))).
+		Watches(&certsv1.Issuer{}, handler.EnqueueRequestsFromMapFunc(r.certificatesForIssuer), builder.WithPredicates(issuerReadinessChanged())).
+		Watches(&certsv1.ClusterIssuer{}, handler.EnqueueRequestsFromMapFunc(r.certificatesForIssuer), builder.WithPredicates(issuerReadinessChanged())).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
+// certificatesForPasswordSecret enqueues the certificates whose keystores are protected by a password of the secret
+func (r *CertificateReconciler) certificatesForPasswordSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var certs certsv1.CertificateList
+	if err := r.List(ctx, &certs, client.InNamespace(secret.GetNamespace()), client.MatchingFields{secretutil.PasswordSecretIndex: secret.GetName()}); err != nil {
+		r.Logger.Error(err, "failed to list certificates of password secret", "SecretName", secret.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(certs.Items))
+	for _, cert := range certs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cert)})
+	}
+	return r.dependencyChanged(requests)
+}
+
+// certificatesForCASecret enqueues the certificates signed by the issuers whose CA keypair is stored in the
+// secret, so that they are reissued once the CA is rotated. The issuers are looked up through the CA secret index
+// registered by the issuer controllers, in every namespace, as the secrets of the cluster resource namespace are
+// watched even when its issuers are not
+func (r *CertificateReconciler) certificatesForCASecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	byCASecret := client.MatchingFields{issuerutil.CASecretNameIndex: secret.GetName()}
+	var issuers certsv1.IssuerList
+	if err := r.List(ctx, &issuers, byCASecret); err != nil {
+		r.Logger.Error(err, "failed to list issuers of CA secret", "SecretName", secret.GetName())
+		return nil
+	}
+	var clusterIssuers certsv1.ClusterIssuerList
+	if secret.GetNamespace() == issuerutil.SecretNamespace(&certsv1.ClusterIssuer{}, r.ClusterResourceNamespace) {
+		if err := r.List(ctx, &clusterIssuers, byCASecret); err != nil {
+			r.Logger.Error(err, "failed to list ClusterIssuers of CA secret", "SecretName", secret.GetName())
+			return nil
+		}
+	}
+
+	var requests []reconcile.Request
+	enqueue := func(issuer certsv1.GenericIssuer, kind string, opts ...client.ListOption) {
+		if issuerutil.SecretNamespace(issuer, r.ClusterResourceNamespace) != secret.GetNamespace() {
+			return
+		}
+		var certs certsv1.CertificateList
+		opts = append(opts, client.MatchingFields{issuerutil.IssuerRefIndex: issuerutil.IssuerRefKey(kind, issuer.GetName())})
+		if err := r.List(ctx, &certs, opts...); err != nil {
+			r.Logger.Error(err, "failed to list certificates of issuer", "IssuerName", issuer.GetName())
+			return
+		}
+		for _, cert := range certs.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cert)})
+		}
+	}
+	for i := range issuers.Items {
+		enqueue(&issuers.Items[i], certsv1.IssuerKind, client.InNamespace(issuers.Items[i].Namespace))
+	}
+	for i := range clusterIssuers.Items {
+		enqueue(&clusterIssuers.Items[i], certsv1.ClusterIssuerKind)
+	}
+	return r.dependencyChanged(requests)
+}
+
+// certificatesForIssuer enqueues the certificates signed by the issuer, so that the certificates waiting for
+// the issuer to become ready are issued right away
+func (r *CertificateReconciler) certificatesForIssuer(ctx context.Context, issuer client.Object) []reconcile.Request {
+	opts := []client.ListOption{client.MatchingFields{issuerutil.IssuerRefIndex: issuerutil.IssuerRefKey(certsv1.ClusterIssuerKind, issuer.GetName())}}
+	if _, isIssuer := issuer.(*certsv1.Issuer); isIssuer {
+		opts = []client.ListOption{
+			client.InNamespace(issuer.GetNamespace()),
+			client.MatchingFields{issuerutil.IssuerRefIndex: issuerutil.IssuerRefKey(certsv1.IssuerKind, issuer.GetName())},
+		}
+	}
+	var certs certsv1.CertificateList
+	if err := r.List(ctx, &certs, opts...); err != nil {
+		r.Logger.Error(err, "failed to list certificates of issuer", "IssuerName", issuer.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(certs.Items))
+	for _, cert := range certs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cert)})
+	}
+	return r.dependencyChanged(requests)
+}
+
+// issuerReadinessChanged filters the updates of issuers down to the changes of their Ready condition
+func issuerReadinessChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldIssuer, oldOk := e.ObjectOld.(certsv1.GenericIssuer)
+			newIssuer, newOk := e.ObjectNew.(certsv1.GenericIssuer)
+			if !oldOk || !newOk {
+				return false
+			}
+			return meta.IsStatusConditionTrue(oldIssuer.GetStatus().Conditions, certsv1.IssuerConditionReady) !=
+				meta.IsStatusConditionTrue(newIssuer.GetStatus().Conditions, certsv1.IssuerConditionReady)
+		},
+	}
+}
+
 func (r *CertificateReconciler) RecordAndLogInfo(cert *certsv1.Certificate, message, reason string) {
 	r.Logger.Info(message, "Reason", reason)
 	r.Recorder.Event(cert, corev1.EventTypeNormal, message, reason)
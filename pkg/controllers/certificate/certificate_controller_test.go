@@ -2,20 +2,43 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	goruntime "runtime"
+	"strings"
 	"testing"
+	"time"
 
 	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
 	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	issuerutil "github.com/AKI-25/certaur/pkg/util/issuer"
+	"github.com/AKI-25/certaur/pkg/util/keystore"
 	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	. "github.com/onsi/ginkgo/v2"
 )
@@ -33,7 +56,14 @@ func TestCertificateController(t *testing.T) {
 	_ = certsv1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
 
-	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&certsv1.Certificate{}, &certsv1.Issuer{}).
+		WithIndex(&corev1.Secret{}, secretutil.OwnerUIDIndex, secretutil.IndexOwnerUID).
+		WithIndex(&certsv1.Certificate{}, secretutil.PasswordSecretIndex, secretutil.IndexPasswordSecrets).
+		WithIndex(&certsv1.Certificate{}, issuerutil.IssuerRefIndex, issuerutil.IndexIssuerRef).
+		WithIndex(&certsv1.Issuer{}, issuerutil.CASecretNameIndex, issuerutil.IndexCASecretName).
+		WithIndex(&certsv1.ClusterIssuer{}, issuerutil.CASecretNameIndex, issuerutil.IndexCASecretName).
+		Build()
 
 	logger := zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))
 	recorder := &FakeRecorder{}
@@ -85,7 +115,7 @@ func TestCertificateController(t *testing.T) {
 		// Verify events were recorded
 		assert.Contains(t, recorder.Events, "SecretCreationSuccessful")
 
-		err = secretutil.EnsureSecretIntegrity(ctx, reconciler.Client, cert, secret)
+		err = secretutil.EnsureSecretIntegrity(ctx, reconciler.Client, cert, secret, certificateutil.IssueOptions{})
 		assert.NoError(t, err)
 
 		// Clean up after test
@@ -128,35 +158,1749 @@ func TestCertificateController(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Step 3: Tamper with the Secret (replace with a valid but incorrect private key)
-		tamperedKeyPEM, err := certificateutil.GeneratePrivateKeyPEM()
+		tamperedKeyPEM := generateTestKeyPEM(t)
+
+		secret.Data["tls.key"] = tamperedKeyPEM
+		err = fakeClient.Update(context.TODO(), secret)
+		assert.NoError(t, err)
+
+		// Step 4: Simulate Reconcile after tampering
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// Step 5: Check if the Secret was fixed (restored to valid data)
+		fixedSecret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, fixedSecret)
+		assert.NoError(t, err)
+
+		err = secretutil.EnsureSecretIntegrity(ctx, reconciler.Client, cert, fixedSecret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+
+		// Verify that events were recorded for tampered detection and fix
+		assert.Contains(t, recorder.Events, "SecretIntegrityCheckFailed")
+		assert.Contains(t, recorder.Events, "SecretIntegrityRestored")
+
+		// Cleanup after test
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Multiple DNS Names", func(t *testing.T) {
+		// Create a Certificate CR requesting several DNS names
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: testSecretName},
+				DnsName:   "test.example.com",
+				DNSNames:  []string{"test", "test.default", "test.default.svc.cluster.local"},
+				Validity:  "365d",
+			},
+		}
+
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// Every requested name must be part of the issued certificate
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"test.example.com", "test", "test.default", "test.default.svc.cluster.local"}, parsedCert.DNSNames)
+
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Adding a name to the CR must be detected as drift
+		cert.Spec.DNSNames = append(cert.Spec.DNSNames, "test.example.org")
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("IP, URI and Email SANs", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef:      certsv1.SecretReference{Name: testSecretName},
+				DNSNames:       []string{"test.example.com"},
+				IPAddresses:    []string{"10.96.0.10"},
+				URIs:           []string{"spiffe://cluster.local/ns/default/sa/test"},
+				EmailAddresses: []string{"admin@example.com"},
+				Validity:       "365d",
+			},
+		}
+
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, "10.96.0.10", parsedCert.IPAddresses[0].String())
+		assert.Equal(t, "spiffe://cluster.local/ns/default/sa/test", parsedCert.URIs[0].String())
+		assert.Equal(t, []string{"admin@example.com"}, parsedCert.EmailAddresses)
+
+		// Removing a SAN from the CR must be detected as drift and trigger a reissue
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.IPAddresses = nil
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err = secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Empty(t, parsedCert.IPAddresses)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Certificate Subject", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: testSecretName},
+				DNSNames:  []string{"test.example.com"},
+				Subject: &certsv1.X509Subject{
+					CommonName:          "test.example.com",
+					Organizations:       []string{"certaur"},
+					OrganizationalUnits: []string{"platform"},
+					Countries:           []string{"TN"},
+				},
+				Validity: "365d",
+			},
+		}
+
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, "test.example.com", parsedCert.Subject.CommonName)
+		assert.Equal(t, []string{"certaur"}, parsedCert.Subject.Organization)
+		assert.Equal(t, []string{"platform"}, parsedCert.Subject.OrganizationalUnit)
+		assert.Equal(t, []string{"TN"}, parsedCert.Subject.Country)
+
+		// A change of the subject must be detected as drift
+		cert.Spec.Subject.Organizations = []string{"k8c"}
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Private Key Algorithm", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef:  certsv1.SecretReference{Name: testSecretName},
+				DNSNames:   []string{"test.example.com"},
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.ECDSAKeyAlgorithm, Size: 384},
+				Validity:   "365d",
+			},
+		}
+
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, x509.ECDSA, parsedCert.PublicKeyAlgorithm)
+		assert.Zero(t, parsedCert.KeyUsage&x509.KeyUsageKeyEncipherment)
+
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Switching to Ed25519 must be detected as drift and trigger a reissue
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.PrivateKey = &certsv1.CertificatePrivateKey{Algorithm: certsv1.Ed25519KeyAlgorithm}
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err = secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, x509.Ed25519, parsedCert.PublicKeyAlgorithm)
+
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Private Key Encoding", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef:  certsv1.SecretReference{Name: testSecretName},
+				DNSNames:   []string{"test.example.com"},
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.RSAKeyAlgorithm, Encoding: certsv1.PKCS1KeyEncoding},
+				Validity:   "365d",
+			},
+		}
+
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		block, _ := pem.Decode(secret.Data["tls.key"])
+		assert.Equal(t, "RSA PRIVATE KEY", block.Type)
+
+		// Switching to PKCS8 must re-encode the key stored in the secret
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.PrivateKey.Encoding = certsv1.PKCS8KeyEncoding
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		block, _ = pem.Decode(secret.Data["tls.key"])
+		assert.Equal(t, "PRIVATE KEY", block.Type)
+
+		_, err = secretutil.ExtractKeyData(cert, secret)
+		assert.NoError(t, err)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Certificate Duration", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "duration-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "duration-secret"},
+				DNSNames:  []string{"ci.example.com"},
+				Duration:  &metav1.Duration{Duration: 90 * time.Minute},
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "duration-cert",
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "duration-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, 90*time.Minute, parsedCert.NotAfter.Sub(parsedCert.NotBefore))
+
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// A different duration must reissue the certificate
+		cert.Spec.Duration = &metav1.Duration{Duration: 2 * time.Hour}
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Backdated Issuance", func(t *testing.T) {
+		backdatingReconciler := &CertificateReconciler{
+			Client:   fakeClient,
+			Scheme:   scheme,
+			Logger:   logger,
+			Recorder: recorder,
+			Backdate: 5 * time.Minute,
+		}
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backdated-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "backdated-secret"},
+				DNSNames:  []string{"skew.example.com"},
+				Validity:  "30d",
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "backdated-cert",
+				Namespace: "default",
+			},
+		}
+		_, err = backdatingReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// NotBefore is backdated while NotAfter is computed from the issuance time
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "backdated-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(-5*time.Minute), parsedCert.NotBefore, 5*time.Second)
+		assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), parsedCert.NotAfter, 5*time.Second)
+
+		// A freshly issued certificate must not be reported as drifted
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{Backdate: 5 * time.Minute})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = backdatingReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		reconciled := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "backdated-secret", Namespace: "default"}, reconciled)
+		assert.NoError(t, err)
+		assert.Equal(t, secret.Data["tls.crt"], reconciled.Data["tls.crt"])
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Lifetime Comparison", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		assert.NoError(t, err)
+		day := 24 * time.Hour
+
+		// Across the spring DST change, a calendar day is only 23 hours long
+		beforeDST := time.Date(2024, time.March, 9, 12, 0, 0, 0, newYork)
+		assert.True(t, certificateutil.CheckCertLifetime(beforeDST, beforeDST.Add(day), day, 0))
+		assert.False(t, certificateutil.CheckCertLifetime(beforeDST, beforeDST.AddDate(0, 0, 1), day, 0))
+
+		// Across the autumn DST change, a calendar day is 25 hours long
+		beforeDSTEnd := time.Date(2024, time.November, 2, 12, 0, 0, 0, newYork)
+		assert.True(t, certificateutil.CheckCertLifetime(beforeDSTEnd, beforeDSTEnd.Add(30*day), 30*day, 0))
+		assert.False(t, certificateutil.CheckCertLifetime(beforeDSTEnd, beforeDSTEnd.AddDate(0, 0, 30), 30*day, 0))
+
+		// The comparison does not depend on the time zone of the parsed times
+		assert.True(t, certificateutil.CheckCertLifetime(beforeDST.UTC(), beforeDST.Add(day).In(newYork), day, 0))
+
+		// A year starting on a leap day is 365 days of 24 hours, not a calendar year
+		leapDay := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+		assert.True(t, certificateutil.CheckCertLifetime(leapDay, leapDay.Add(365*day), 365*day, 0))
+		assert.False(t, certificateutil.CheckCertLifetime(leapDay, leapDay.AddDate(1, 0, 0), 365*day, 0))
+
+		// Certificate times are truncated to the second and may be backdated
+		issuedAt := time.Date(2024, time.December, 31, 23, 59, 59, 900_000_000, time.UTC)
+		notBefore := issuedAt.Add(-time.Minute).Truncate(time.Second)
+		notAfter := issuedAt.Add(day).Truncate(time.Second)
+		assert.True(t, certificateutil.CheckCertLifetime(notBefore, notAfter, day, time.Minute))
+		assert.False(t, certificateutil.CheckCertLifetime(notBefore, notAfter, day, 0))
+		assert.False(t, certificateutil.CheckCertLifetime(notBefore, notBefore.Add(day-2*time.Second), day, time.Minute))
+	})
+
+	t.Run("Private Key Rotation", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rotation-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef:  certsv1.SecretReference{Name: "rotation-secret"},
+				DNSNames:   []string{"pinned.example.com"},
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.ECDSAKeyAlgorithm, RotationPolicy: certsv1.NeverRotationPolicy},
+				Validity:   "365d",
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "rotation-cert",
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "rotation-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		originalKey := secret.Data["tls.key"]
+
+		// Reissuing with the Never policy must keep the private key
+		err = secretutil.EnsureSecretIntegrity(context.TODO(), fakeClient, cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, originalKey, secret.Data["tls.key"])
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// The key is rotated when it no longer matches the requested algorithm
+		cert.Spec.PrivateKey.Size = 384
+		err = secretutil.EnsureSecretIntegrity(context.TODO(), fakeClient, cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.NotEqual(t, originalKey, secret.Data["tls.key"])
+		rotatedKey := secret.Data["tls.key"]
+
+		// Reissuing with the Always policy must generate a new private key
+		cert.Spec.PrivateKey.RotationPolicy = certsv1.AlwaysRotationPolicy
+		err = secretutil.EnsureSecretIntegrity(context.TODO(), fakeClient, cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.NotEqual(t, rotatedKey, secret.Data["tls.key"])
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Key Usages", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "usages-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef:  certsv1.SecretReference{Name: "usages-secret"},
+				DNSNames:   []string{"client.example.com"},
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.ECDSAKeyAlgorithm},
+				Usages:     []certsv1.KeyUsage{certsv1.UsageDigitalSignature, certsv1.UsageKeyEncipherment, certsv1.UsageClientAuth, certsv1.UsageServerAuth},
+				Validity:   "365d",
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "usages-cert",
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// Key encipherment must be dropped for ECDSA keys
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "usages-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, x509.KeyUsageDigitalSignature, parsedCert.KeyUsage)
+		assert.ElementsMatch(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth}, parsedCert.ExtKeyUsage)
+
+		// Changing the usages must reissue the certificate
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.Usages = []certsv1.KeyUsage{certsv1.UsageDigitalSignature, certsv1.UsageCodeSigning}
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "usages-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err = secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}, parsedCert.ExtKeyUsage)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Certificate Status", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "status-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "status-secret"},
+				DNSNames:  []string{"test.example.com"},
+				Validity:  "365d",
+			},
+		}
+
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "status-cert",
+				Namespace: "default",
+			},
+		}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "status-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+
+		// The status must describe the issued certificate
+		assert.True(t, meta.IsStatusConditionTrue(cert.Status.Conditions, certsv1.CertificateConditionReady))
+		assert.Equal(t, 1, cert.Status.Revision)
+		assert.Equal(t, cert.Generation, cert.Status.ObservedGeneration)
+		assert.Equal(t, fmt.Sprintf("%X", parsedCert.SerialNumber), cert.Status.SerialNumber)
+		// Serial numbers are random 128-bit numbers rather than timestamps
+		assert.Greater(t, parsedCert.SerialNumber.BitLen(), 64)
+		assert.Equal(t, certificateutil.Fingerprint(parsedCert), cert.Status.FingerprintSHA256)
+		assert.True(t, parsedCert.NotAfter.Equal(cert.Status.NotAfter.Time))
+		assert.True(t, parsedCert.NotBefore.Equal(cert.Status.NotBefore.Time))
+
+		// Reconciling a valid certificate must not bump the revision
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, cert.Status.Revision)
+
+		// Reissuing the certificate after tampering must bump the revision
+		tamperedKeyPEM := generateTestKeyPEM(t)
+		secret.Data["tls.key"] = tamperedKeyPEM
+		err = fakeClient.Update(context.TODO(), secret)
+		assert.NoError(t, err)
+
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, cert.Status.Revision)
+		assert.NotEqual(t, fmt.Sprintf("%X", parsedCert.SerialNumber), cert.Status.SerialNumber)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Certificate Renewal", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "renewal-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "renewal-secret"},
+				DNSNames:  []string{"test.example.com"},
+				Validity:  "90d",
+			},
+		}
+
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		// Store a certificate that matches the spec but is past its renewal time
+		notBefore := time.Now().Add(-80 * 24 * time.Hour).Truncate(time.Second)
+		crtPEM, keyPEM := generateTestCertificate(t, cert.Spec.DNSNames, notBefore, notBefore.Add(90*24*time.Hour))
+		err = secretutil.CreateSecret(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default"}}, fakeClient, context.TODO(), cert, "renewal-secret", crtPEM, keyPEM, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "renewal-cert",
+				Namespace: "default",
+			},
+		}
+		result, err := reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Contains(t, recorder.Events, "CertificateRenewed")
+
+		// The renewed certificate must be valid for another 90 days
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "renewal-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.True(t, parsedCert.NotAfter.After(time.Now().Add(89*24*time.Hour)))
+
+		// The status must record the renewal and the controller must come back at the next renewal time
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, cert.Status.Revision)
+		assert.True(t, cert.Status.RenewalTime.Time.Equal(parsedCert.NotAfter.Add(-30*24*time.Hour)))
+		assert.InDelta(t, float64(60*24*time.Hour), float64(result.RequeueAfter), float64(time.Minute))
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("CA Issuer", func(t *testing.T) {
+		// Store a CA keypair and an Issuer referencing it
+		caCertPEM, caKeyPEM := generateTestCA(t)
+		caSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+			Data:       map[string][]byte{"tls.crt": caCertPEM, "tls.key": caKeyPEM},
+			Type:       corev1.SecretTypeTLS,
+		}
+		err := fakeClient.Create(context.TODO(), caSecret)
+		assert.NoError(t, err)
+
+		issuer := &certsv1.Issuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-issuer", Namespace: "default"},
+			Spec:       certsv1.IssuerSpec{CA: &certsv1.CAIssuer{SecretName: "ca-secret"}},
+		}
+		err = fakeClient.Create(context.TODO(), issuer)
+		assert.NoError(t, err)
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ca-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "ca-signed-secret"},
+				DNSNames:  []string{"test.example.com"},
+				IssuerRef: &certsv1.IssuerReference{Name: "ca-issuer", Kind: certsv1.IssuerKind},
+				Validity:  "365d",
+			},
+		}
+		err = fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "ca-cert",
+				Namespace: "default",
+			},
+		}
+
+		// The certificate must not be issued until the issuer is ready, it is retried after a backoff
+		result, err := reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, initialIssuanceBackoff, result.RequeueAfter)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		readyCondition := meta.FindStatusCondition(cert.Status.Conditions, certsv1.CertificateConditionReady)
+		assert.Equal(t, metav1.ConditionFalse, readyCondition.Status)
+		assert.Equal(t, "IssuerNotReady", readyCondition.Reason)
+		assert.Equal(t, int32(1), cert.Status.FailedIssuanceAttempts)
+
+		meta.SetStatusCondition(&issuer.Status.Conditions, metav1.Condition{
+			Type:   certsv1.IssuerConditionReady,
+			Status: metav1.ConditionTrue,
+			Reason: "KeyPairVerified",
+		})
+		oldIssuer := issuer.DeepCopy()
+		oldIssuer.Status.Conditions = nil
+		err = fakeClient.Status().Update(context.TODO(), issuer)
+		assert.NoError(t, err)
+
+		// The issuer becoming ready enqueues its certificates, which skip the rest of their backoff
+		assert.True(t, issuerReadinessChanged().Update(event.UpdateEvent{ObjectOld: oldIssuer, ObjectNew: issuer}))
+		assert.False(t, issuerReadinessChanged().Update(event.UpdateEvent{ObjectOld: issuer, ObjectNew: issuer}))
+		assert.Equal(t, []reconcile.Request{req}, reconciler.certificatesForIssuer(context.TODO(), issuer))
+		result, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter, time.Hour)
+
+		// The certificate must be signed by the CA, which is stored in ca.crt
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "ca-signed-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, caCertPEM, secret.Data["ca.crt"])
+
+		caCert, err := certificateutil.ParseCertificate(caCertPEM)
+		assert.NoError(t, err)
+		parsedCert, err := secretutil.ExtractCertData(cert, secret)
+		assert.NoError(t, err)
+		assert.NoError(t, parsedCert.CheckSignatureFrom(caCert))
+
+		// The certificate does not outlive its CA, which expires before the requested validity, and
+		// is not reissued for its shorter lifetime
+		assert.True(t, parsedCert.NotAfter.Equal(caCert.NotAfter))
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{CA: mustExtractCA(t, caSecret)})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		reconciled := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "ca-signed-secret", Namespace: "default"}, reconciled)
+		assert.NoError(t, err)
+		assert.Equal(t, secret.Data["tls.crt"], reconciled.Data["tls.crt"])
+
+		// A change of the CA secret, which is not labelled as managed, enqueues the certificates of its issuer
+		assert.NotContains(t, caSecret.Labels, secretutil.ManagedLabel)
+		assert.Equal(t, []reconcile.Request{req}, reconciler.certificatesForCASecret(context.TODO(), caSecret))
+
+		// A self-signed certificate must be reissued once the certificate references the CA issuer
+		ca, err := certificateutil.ParseCA(caCertPEM, caKeyPEM)
+		assert.NoError(t, err)
+		err = secretutil.EnsureSecretIntegrity(context.TODO(), fakeClient, cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{CA: ca})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Intermediate CA", func(t *testing.T) {
+		// Issue an intermediate CA from the CA issuer created above
+		intermediate := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "intermediate-ca",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef:           certsv1.SecretReference{Name: "intermediate-ca-secret"},
+				Subject:             &certsv1.X509Subject{CommonName: "certaur intermediate CA"},
+				IssuerRef:           &certsv1.IssuerReference{Name: "ca-issuer", Kind: certsv1.IssuerKind},
+				IsCA:                true,
+				PermittedDNSDomains: []string{"example.com"},
+				Validity:            "30d",
+			},
+		}
+		err := fakeClient.Create(context.TODO(), intermediate)
+		assert.NoError(t, err)
+
+		_, err = reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "intermediate-ca", Namespace: "default"}})
+		assert.NoError(t, err)
+
+		intermediateSecret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "intermediate-ca-secret", Namespace: "default"}, intermediateSecret)
+		assert.NoError(t, err)
+		intermediateCert, err := secretutil.ExtractCertData(intermediate, intermediateSecret)
+		assert.NoError(t, err)
+		assert.True(t, intermediateCert.IsCA)
+		assert.True(t, intermediateCert.MaxPathLenZero)
+		assert.NotZero(t, intermediateCert.KeyUsage&x509.KeyUsageCertSign)
+		assert.Equal(t, []string{"example.com"}, intermediateCert.PermittedDNSDomains)
+		assert.Len(t, decodeCertificates(t, intermediateSecret.Data["tls.crt"]), 2)
+
+		// Hand the intermediate CA over to an issuer signing leaf certificates
+		issuer := &certsv1.Issuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "intermediate-issuer", Namespace: "default"},
+			Spec:       certsv1.IssuerSpec{CA: &certsv1.CAIssuer{SecretName: "intermediate-ca-secret"}},
+			Status: certsv1.IssuerStatus{Conditions: []metav1.Condition{{
+				Type:               certsv1.IssuerConditionReady,
+				Status:             metav1.ConditionTrue,
+				Reason:             "KeyPairVerified",
+				LastTransitionTime: metav1.Now(),
+			}}},
+		}
+		err = fakeClient.Create(context.TODO(), issuer)
+		assert.NoError(t, err)
+		err = fakeClient.Status().Update(context.TODO(), issuer)
+		assert.NoError(t, err)
+
+		leaf := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "leaf-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "leaf-secret"},
+				DNSNames:  []string{"app.example.com"},
+				IssuerRef: &certsv1.IssuerReference{Name: "intermediate-issuer", Kind: certsv1.IssuerKind},
+				Validity:  "10d",
+			},
+		}
+		err = fakeClient.Create(context.TODO(), leaf)
+		assert.NoError(t, err)
+
+		_, err = reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "leaf-cert", Namespace: "default"}})
+		assert.NoError(t, err)
+
+		// The leaf secret must hold the full chain up to the root, which is stored in ca.crt
+		leafSecret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "leaf-secret", Namespace: "default"}, leafSecret)
+		assert.NoError(t, err)
+		chain := decodeCertificates(t, leafSecret.Data["tls.crt"])
+		assert.Len(t, chain, 3)
+
+		roots := x509.NewCertPool()
+		assert.True(t, roots.AppendCertsFromPEM(leafSecret.Data["ca.crt"]))
+		intermediates := x509.NewCertPool()
+		for _, c := range chain[1:] {
+			intermediates.AddCert(c)
+		}
+		_, err = chain[0].Verify(x509.VerifyOptions{
+			DNSName:       "app.example.com",
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		assert.NoError(t, err)
+
+		ok, err := secretutil.CheckSecretIntegrity(leaf, leafSecret, certificateutil.IssueOptions{CA: mustExtractCA(t, intermediateSecret)})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// The intermediate CA has a maxPathLen of 0 and must not sign further CAs
+		subCA := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "sub-ca",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "sub-ca-secret"},
+				Subject:   &certsv1.X509Subject{CommonName: "certaur sub CA"},
+				IssuerRef: &certsv1.IssuerReference{Name: "intermediate-issuer", Kind: certsv1.IssuerKind},
+				IsCA:      true,
+				Validity:  "10d",
+			},
+		}
+		err = fakeClient.Create(context.TODO(), subCA)
+		assert.NoError(t, err)
+
+		result, err := reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "sub-ca", Namespace: "default"}})
+		assert.NoError(t, err)
+		assert.NotZero(t, result.RequeueAfter)
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "sub-ca-secret", Namespace: "default"}, &corev1.Secret{})
+		assert.True(t, apierrors.IsNotFound(err))
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "sub-ca", Namespace: "default"}, subCA)
+		assert.NoError(t, err)
+		readyCondition := meta.FindStatusCondition(subCA.Status.Conditions, certsv1.CertificateConditionReady)
+		assert.Equal(t, metav1.ConditionFalse, readyCondition.Status)
+		assert.Equal(t, "IssuerPathLenExceeded", readyCondition.Reason)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, subCA)
+			_ = fakeClient.Delete(ctx, leaf)
+			_ = fakeClient.Delete(ctx, intermediate)
+		})
+	})
+
+	t.Run("Keystores", func(t *testing.T) {
+		passwordSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keystore-password", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("changeit")},
+		}
+		err := fakeClient.Create(context.TODO(), passwordSecret)
+		assert.NoError(t, err)
+
+		passwordRef := certsv1.SecretKeySelector{Name: "keystore-password", Key: "password"}
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "keystore-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "keystore-secret"},
+				DNSNames:  []string{"java.example.com"},
+				IssuerRef: &certsv1.IssuerReference{Name: "ca-issuer", Kind: certsv1.IssuerKind},
+				Validity:  "30d",
+				Keystores: &certsv1.CertificateKeystores{
+					PKCS12: &certsv1.PKCS12Keystore{Create: true, PasswordSecretRef: passwordRef},
+					JKS:    &certsv1.JKSKeystore{Create: true, PasswordSecretRef: passwordRef},
+				},
+			},
+		}
+		err = fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "keystore-cert", Namespace: "default"}}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// The keystores hold the certificate and its key, the truststores hold the CA
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "keystore-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		for _, key := range []string{keystore.PKCS12KeystoreKey, keystore.PKCS12TruststoreKey, keystore.JKSKeystoreKey, keystore.JKSTruststoreKey} {
+			assert.Contains(t, secret.Data, key)
+		}
+
+		key, err := certificateutil.ParsePrivateKey(secret.Data["tls.key"])
+		assert.NoError(t, err)
+		chain := decodeCertificates(t, secret.Data["tls.crt"])
+		assert.True(t, keystore.CheckJKSKeystore(secret.Data[keystore.JKSKeystoreKey], "changeit", key, chain))
+		assert.True(t, keystore.CheckPKCS12(secret.Data[keystore.PKCS12KeystoreKey], "changeit", key, chain))
+		assert.True(t, keystore.CheckJKSTrustStore(secret.Data[keystore.JKSTruststoreKey], "changeit", decodeCertificates(t, secret.Data["ca.crt"])))
+
+		// The password secret is not written to, the changes of its metadata enqueue the certificate
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "keystore-password", Namespace: "default"}, passwordSecret)
+		assert.NoError(t, err)
+		assert.NotContains(t, passwordSecret.Labels, secretutil.ManagedLabel)
+		passwordMetadata := secretutil.SecretMetadata()
+		passwordMetadata.ObjectMeta = passwordSecret.ObjectMeta
+		assert.Equal(t, []reconcile.Request{req}, reconciler.certificatesForPasswordSecret(context.TODO(), passwordMetadata))
+		assert.Empty(t, reconciler.certificatesForPasswordSecret(context.TODO(), secret))
+
+		caSecret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "ca-secret", Namespace: "default"}, caSecret)
+		assert.NoError(t, err)
+		opts := certificateutil.IssueOptions{
+			CA:                mustExtractCA(t, caSecret),
+			KeystorePasswords: keystore.Passwords{PKCS12: "changeit", JKS: "changeit"},
+		}
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, opts)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// A password change must be detected as drift
+		changed := opts
+		changed.KeystorePasswords.JKS = "rotated"
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, changed)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		// A tampered keystore must be detected and regenerated
+		secret.Data[keystore.PKCS12KeystoreKey] = []byte("tampered")
+		err = fakeClient.Update(context.TODO(), secret)
+		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "keystore-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, opts)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Keystores that are no longer requested must be removed from the secret
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.Keystores.JKS = nil
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "keystore-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.NotContains(t, secret.Data, keystore.JKSKeystoreKey)
+		assert.NotContains(t, secret.Data, keystore.JKSTruststoreKey)
+		assert.Contains(t, secret.Data, keystore.PKCS12KeystoreKey)
+		assert.Equal(t, keystore.DefaultPKCS12Profile, secret.Annotations[secretutil.PKCS12ProfileAnnotation])
+
+		// A change of the PKCS#12 profile must be detected as drift and the keystores encoded again
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.Keystores.PKCS12.Profile = certsv1.LegacyRC2PKCS12Profile
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, opts)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "keystore-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, string(certsv1.LegacyRC2PKCS12Profile), secret.Annotations[secretutil.PKCS12ProfileAnnotation])
+		key, err = certificateutil.ParsePrivateKey(secret.Data["tls.key"])
+		assert.NoError(t, err)
+		assert.True(t, keystore.CheckPKCS12(secret.Data[keystore.PKCS12KeystoreKey], "changeit", key, decodeCertificates(t, secret.Data["tls.crt"])))
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, opts)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// A missing password secret must keep the certificate not ready and back off
+		err = fakeClient.Delete(context.TODO(), passwordSecret)
+		assert.NoError(t, err)
+		result, err := reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, initialIssuanceBackoff, result.RequeueAfter)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		readyCondition := meta.FindStatusCondition(cert.Status.Conditions, certsv1.CertificateConditionReady)
+		assert.Equal(t, "KeystorePasswordUnavailable", readyCondition.Reason)
+
+		// the password secret is not read again before the backoff is over
+		events := len(recorder.Events)
+		result, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter, time.Duration(0))
+		assert.Len(t, recorder.Events, events)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), cert.Status.FailedIssuanceAttempts)
+
+		// Recreating the password secret enqueues the certificate, which skips the rest of its backoff
+		passwordSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keystore-password", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("changeit")},
+		}
+		err = fakeClient.Create(context.TODO(), passwordSecret)
+		assert.NoError(t, err)
+		passwordMetadata.ObjectMeta = passwordSecret.ObjectMeta
+		assert.Equal(t, []reconcile.Request{req}, reconciler.certificatesForPasswordSecret(context.TODO(), passwordMetadata))
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.True(t, meta.IsStatusConditionTrue(cert.Status.Conditions, certsv1.CertificateConditionReady))
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Secret Template", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "template-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "template-secret"},
+				DNSNames:  []string{"haproxy.example.com"},
+				Validity:  "30d",
+				SecretTemplate: &certsv1.CertificateSecretTemplate{
+					Keys: &certsv1.CertificateSecretKeys{Certificate: "cert.pem", PrivateKey: "key.pem"},
+					AdditionalOutputFormats: []certsv1.CertificateAdditionalOutputFormat{
+						{Type: certsv1.CombinedPEMOutputFormat},
+						{Type: certsv1.DEROutputFormat},
+					},
+				},
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "template-cert", Namespace: "default"}}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// Custom key names turn the secret into an Opaque secret holding the additional outputs
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "template-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, corev1.SecretTypeOpaque, secret.Type)
+		assert.NotContains(t, secret.Data, corev1.TLSCertKey)
+		assert.NotContains(t, secret.Data, corev1.TLSPrivateKeyKey)
+		assert.Equal(t, append(append([]byte{}, secret.Data["key.pem"]...), secret.Data["cert.pem"]...), secret.Data[secretutil.CombinedPEMKey])
+		block, _ := pem.Decode(secret.Data["key.pem"])
+		assert.NotNil(t, block)
+		assert.Equal(t, block.Bytes, secret.Data[secretutil.DERKey])
+
+		ok, err := secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Back to the default key names, the Opaque secret is recreated as a TLS secret without the previous keys
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.SecretTemplate = nil
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.True(t, meta.IsStatusConditionTrue(cert.Status.Conditions, certsv1.CertificateConditionReady))
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "template-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+		assert.Contains(t, secret.Data, corev1.TLSCertKey)
+		for _, key := range []string{"cert.pem", "key.pem", secretutil.CombinedPEMKey, secretutil.DERKey} {
+			assert.NotContains(t, secret.Data, key)
+		}
+		ok, err = secretutil.CheckSecretIntegrity(cert, secret, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Secret Labels and Annotations", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "labelled-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "labelled-secret"},
+				DNSNames:  []string{"labelled.example.com"},
+				Validity:  "30d",
+				SecretTemplate: &certsv1.CertificateSecretTemplate{
+					Labels:      map[string]string{"app": "web", "tier": "frontend"},
+					Annotations: map[string]string{"replicator.v1.mittwald.de/replicate-to": "prod"},
+				},
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "labelled-cert", Namespace: "default"}}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "labelled-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, "web", secret.Labels["app"])
+		assert.Equal(t, "frontend", secret.Labels["tier"])
+		assert.Equal(t, "prod", secret.Annotations["replicator.v1.mittwald.de/replicate-to"])
+		assert.Equal(t, "app,tier", secret.Annotations[secretutil.ManagedLabelsAnnotation])
+
+		// Labels set by other tools must be kept when the template changes
+		secret.Labels["team"] = "payments"
+		err = fakeClient.Update(context.TODO(), secret)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.SecretTemplate.Labels = map[string]string{"app": "api"}
+		cert.Spec.SecretTemplate.Annotations = nil
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		events := len(recorder.Events)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Contains(t, recorder.Events[events:], "SecretTemplateSynced")
+		assert.NotContains(t, recorder.Events[events:], "SecretIntegrityCheckFailed")
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "labelled-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"app": "api", "team": "payments", secretutil.ManagedLabel: "true"}, secret.Labels)
+		assert.NotContains(t, secret.Annotations, "replicator.v1.mittwald.de/replicate-to")
+		assert.NotContains(t, secret.Annotations, secretutil.ManagedAnnotationsAnnotation)
+		assert.Equal(t, "app", secret.Annotations[secretutil.ManagedLabelsAnnotation])
+
+		// A secret in sync with its template is left untouched
+		events = len(recorder.Events)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.NotContains(t, recorder.Events[events:], "SecretTemplateSynced")
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Secret Retention", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "retained-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef:            certsv1.SecretReference{Name: "retained-secret"},
+				DNSNames:             []string{"retained.example.com"},
+				Validity:             "30d",
+				SecretDeletionPolicy: certsv1.RetainSecretDeletionPolicy,
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
 		assert.NoError(t, err)
 
-		secret.Data["tls.key"] = tamperedKeyPEM
-		err = fakeClient.Update(context.TODO(), secret)
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "retained-cert", Namespace: "default"}}
+		_, err = reconciler.Reconcile(context.TODO(), req)
 		assert.NoError(t, err)
 
-		// Step 4: Simulate Reconcile after tampering
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Contains(t, cert.Finalizers, SecretRetentionFinalizer)
+
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "retained-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.True(t, secretutil.IsOwnerReference(cert, secret))
+		assert.Equal(t, "true", secret.Labels[secretutil.ManagedLabel])
+		crtPEM := secret.Data["tls.crt"]
+
+		// Deleting the certificate orphans its secret instead of letting it be garbage collected
+		err = fakeClient.Delete(context.TODO(), cert)
+		assert.NoError(t, err)
 		_, err = reconciler.Reconcile(context.TODO(), req)
 		assert.NoError(t, err)
+		assert.Contains(t, recorder.Events, "SecretRetained")
 
-		// Step 5: Check if the Secret was fixed (restored to valid data)
-		fixedSecret := &corev1.Secret{}
-		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: testSecretName, Namespace: "default"}, fixedSecret)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.True(t, apierrors.IsNotFound(err))
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "retained-secret", Namespace: "default"}, secret)
 		assert.NoError(t, err)
+		assert.Empty(t, secret.OwnerReferences)
+		assert.True(t, secretutil.IsOrphaned(secret))
+		assert.NotContains(t, secret.Labels, secretutil.ManagedLabel)
 
-		err = secretutil.EnsureSecretIntegrity(ctx, reconciler.Client, cert, fixedSecret)
+		// A new certificate referencing the secret adopts it and keeps its valid keypair
+		adopter := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "adopting-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "retained-secret"},
+				DNSNames:  []string{"retained.example.com"},
+				Validity:  "30d",
+			},
+		}
+		err = fakeClient.Create(context.TODO(), adopter)
 		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "adopting-cert", Namespace: "default"}})
+		assert.NoError(t, err)
+		assert.Contains(t, recorder.Events, "SecretAdopted")
 
-		// Verify that events were recorded for tampered detection and fix
-		assert.Contains(t, recorder.Events, "SecretIntegrityCheckFailed")
-		assert.Contains(t, recorder.Events, "SecretIntegrityRestored")
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "retained-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.False(t, secretutil.IsOrphaned(secret))
+		assert.NotContains(t, secret.Labels, secretutil.OrphanedLabel)
+		assert.True(t, secretutil.IsOwnerReference(adopter, secret))
+		assert.Equal(t, "true", secret.Labels[secretutil.ManagedLabel])
+		assert.Equal(t, crtPEM, secret.Data["tls.crt"])
+
+		// Certificates deleting their secret do not hold a finalizer
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "adopting-cert", Namespace: "default"}, adopter)
+		assert.NoError(t, err)
+		assert.NotContains(t, adopter.Finalizers, SecretRetentionFinalizer)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, adopter)
+		})
+	})
+
+	t.Run("Secret Adoption", func(t *testing.T) {
+		// A hand-made TLS secret opted in for adoption
+		dnsNames := []string{"legacy.example.com"}
+		notBefore := time.Now().Truncate(time.Second)
+		crtPEM, keyPEM := generateTestCertificate(t, dnsNames, notBefore, notBefore.Add(30*24*time.Hour))
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "legacy-secret",
+				Namespace:   "default",
+				Annotations: map[string]string{secretutil.AdoptionAnnotation: "true"},
+			},
+			Data: map[string][]byte{"tls.crt": crtPEM, "tls.key": keyPEM},
+			Type: corev1.SecretTypeTLS,
+		}
+		err := fakeClient.Create(context.TODO(), secret)
+		assert.NoError(t, err)
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "legacy-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "legacy-secret"},
+				DNSNames:  dnsNames,
+				Validity:  "30d",
+			},
+		}
+		err = fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy-cert", Namespace: "default"}}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// The certificate takes ownership of the secret and keeps its valid material
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "legacy-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.True(t, secretutil.IsOwnerReference(cert, secret))
+		assert.NotContains(t, secret.Annotations, secretutil.AdoptionAnnotation)
+		assert.Equal(t, crtPEM, secret.Data["tls.crt"])
+		assert.Equal(t, keyPEM, secret.Data["tls.key"])
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		readyCondition := meta.FindStatusCondition(cert.Status.Conditions, certsv1.CertificateConditionReady)
+		assert.Equal(t, metav1.ConditionTrue, readyCondition.Status)
+
+		// Adopted material that does not match the certificate is reissued
+		mismatchPEM, mismatchKeyPEM := generateTestCertificate(t, []string{"other.example.com"}, notBefore, notBefore.Add(30*24*time.Hour))
+		mismatched := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mismatched-secret",
+				Namespace:   "default",
+				Annotations: map[string]string{secretutil.AdoptionAnnotation: "true"},
+			},
+			Data: map[string][]byte{"tls.crt": mismatchPEM, "tls.key": mismatchKeyPEM},
+			Type: corev1.SecretTypeTLS,
+		}
+		err = fakeClient.Create(context.TODO(), mismatched)
+		assert.NoError(t, err)
+		cert.Spec.SecretRef.Name = "mismatched-secret"
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "mismatched-secret", Namespace: "default"}, mismatched)
+		assert.NoError(t, err)
+		assert.True(t, secretutil.IsOwnerReference(cert, mismatched))
+		assert.NotEqual(t, mismatchPEM, mismatched.Data["tls.crt"])
+		ok, err := secretutil.CheckSecretIntegrity(cert, mismatched, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		// Adopted material that cannot be parsed is reissued as well instead of failing the certificate
+		garbage := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "garbage-secret",
+				Namespace:   "default",
+				Annotations: map[string]string{secretutil.AdoptionAnnotation: "true"},
+			},
+			Data: map[string][]byte{"tls.crt": []byte("not a certificate"), "tls.key": []byte("not a key")},
+			Type: corev1.SecretTypeTLS,
+		}
+		err = fakeClient.Create(context.TODO(), garbage)
+		assert.NoError(t, err)
+		ok, err = secretutil.CheckSecretIntegrity(cert, garbage, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		cert.Spec.SecretRef.Name = "garbage-secret"
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		result, err := reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter, time.Hour)
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "garbage-secret", Namespace: "default"}, garbage)
+		assert.NoError(t, err)
+		assert.True(t, secretutil.IsOwnerReference(cert, garbage))
+		ok, err = secretutil.CheckSecretIntegrity(cert, garbage, certificateutil.IssueOptions{})
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.True(t, meta.IsStatusConditionTrue(cert.Status.Conditions, certsv1.CertificateConditionReady))
+		assert.Zero(t, cert.Status.FailedIssuanceAttempts)
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Secret Rename", func(t *testing.T) {
+		reconciler.SecretRenameGracePeriod = time.Hour
+		t.Cleanup(func() {
+			reconciler.SecretRenameGracePeriod = 0
+		})
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "renamed-cert",
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "old-secret"},
+				DNSNames:  []string{"renamed.example.com"},
+				Validity:  "30d",
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "renamed-cert", Namespace: "default"}}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		oldSecret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "old-secret", Namespace: "default"}, oldSecret)
+		assert.NoError(t, err)
+
+		// Renaming the secretRef copies the keypair to the new secret and keeps the old one
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, "old-secret", cert.Status.SecretName)
+		revision := cert.Status.Revision
+		cert.Spec.SecretRef.Name = "new-secret"
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		result, err := reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Contains(t, recorder.Events, "SecretMigrated")
+		assert.LessOrEqual(t, result.RequeueAfter, time.Hour)
+
+		newSecret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "new-secret", Namespace: "default"}, newSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, oldSecret.Data["tls.crt"], newSecret.Data["tls.crt"])
+		assert.Equal(t, oldSecret.Data["tls.key"], newSecret.Data["tls.key"])
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "old-secret", Namespace: "default"}, oldSecret)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, "new-secret", cert.Status.SecretName)
+		if assert.Len(t, cert.Status.PreviousSecrets, 1) {
+			assert.Equal(t, "old-secret", cert.Status.PreviousSecrets[0].Name)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), cert.Status.PreviousSecrets[0].DeletionTime.Time, time.Minute)
+		}
+		assert.Equal(t, revision, cert.Status.Revision)
+
+		// The old secret is kept during the grace period
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "old-secret", Namespace: "default"}, oldSecret)
+		assert.NoError(t, err)
+
+		// and deleted once it is over
+		cert.Status.PreviousSecrets[0].DeletionTime = metav1.Time{Time: time.Now().Add(-time.Second)}
+		err = fakeClient.Status().Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Contains(t, recorder.Events, "PreviousSecretDeleted")
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "old-secret", Namespace: "default"}, oldSecret)
+		assert.True(t, apierrors.IsNotFound(err))
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Empty(t, cert.Status.PreviousSecrets)
+
+		// A keypair that cannot be parsed is not migrated, a new certificate is issued instead
+		newSecret.Data["tls.key"] = []byte("corrupted")
+		err = fakeClient.Update(context.TODO(), newSecret)
+		assert.NoError(t, err)
+		cert.Spec.SecretRef.Name = "third-secret"
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Contains(t, recorder.Events, "SecretMigrationSkipped")
+
+		thirdSecret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "third-secret", Namespace: "default"}, thirdSecret)
+		assert.NoError(t, err)
+		assert.NotEqual(t, oldSecret.Data["tls.crt"], thirdSecret.Data["tls.crt"])
+		_, err = certificateutil.ParsePrivateKey(thirdSecret.Data["tls.key"])
+		assert.NoError(t, err)
+
+		// A second rename during the grace period keeps every previous secret until its own deletion time
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		firstDeletion := metav1.Time{Time: time.Now().Add(30 * time.Minute).Truncate(time.Second)}
+		cert.Status.PreviousSecrets[0].DeletionTime = firstDeletion
+		err = fakeClient.Status().Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		cert.Spec.SecretRef.Name = "fourth-secret"
+		err = fakeClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, "fourth-secret", cert.Status.SecretName)
+		if assert.Len(t, cert.Status.PreviousSecrets, 2) {
+			assert.Equal(t, "new-secret", cert.Status.PreviousSecrets[0].Name)
+			assert.True(t, firstDeletion.Equal(&cert.Status.PreviousSecrets[0].DeletionTime))
+			assert.Equal(t, "third-secret", cert.Status.PreviousSecrets[1].Name)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), cert.Status.PreviousSecrets[1].DeletionTime.Time, time.Minute)
+		}
+		for _, name := range []string{"new-secret", "third-secret", "fourth-secret"} {
+			err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "default"}, &corev1.Secret{})
+			assert.NoError(t, err)
+		}
+
+		t.Cleanup(func() {
+			_ = fakeClient.Delete(ctx, cert)
+		})
+	})
+
+	t.Run("Secret Ownership Lookup", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "indexed-cert",
+				Namespace: "default",
+				UID:       "indexed-cert-uid",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "indexed-secret"},
+				DNSNames:  []string{"indexed.example.com"},
+				Validity:  "30d",
+			},
+		}
+		err := fakeClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "indexed-cert", Namespace: "default"}}
+		_, err = reconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "indexed-secret", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, "true", secret.Labels[secretutil.ManagedLabel])
+		assert.Equal(t, []string{string(cert.UID)}, secretutil.IndexOwnerUID(secret))
+
+		// Only the labelled secrets owned by the certificate are returned, apart from its current secret
+		ownerRefs := []metav1.OwnerReference{*metav1.NewControllerRef(cert, certsv1.GroupVersion.WithKind("Certificate"))}
+		for _, s := range []*corev1.Secret{
+			{ObjectMeta: metav1.ObjectMeta{Name: "indexed-previous", Namespace: "default", OwnerReferences: ownerRefs, Labels: map[string]string{secretutil.ManagedLabel: "true"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "indexed-unlabelled", Namespace: "default", OwnerReferences: ownerRefs}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "indexed-unowned", Namespace: "default", Labels: map[string]string{secretutil.ManagedLabel: "true"}}},
+		} {
+			err = fakeClient.Create(context.TODO(), s)
+			assert.NoError(t, err)
+		}
+
+		owned, err := secretutil.CheckOwnership(context.TODO(), fakeClient, cert)
+		assert.NoError(t, err)
+		if assert.Len(t, owned.Items, 1) {
+			assert.Equal(t, "indexed-previous", owned.Items[0].Name)
+		}
 
-		// Cleanup after test
 		t.Cleanup(func() {
 			_ = fakeClient.Delete(ctx, cert)
+			for _, name := range []string{"indexed-secret", "indexed-previous", "indexed-unlabelled", "indexed-unowned"} {
+				_ = fakeClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}})
+			}
 		})
 	})
 
+	t.Run("Issuance Backoff", func(t *testing.T) {
+		// Secrets cannot be created until failing is cleared
+		failing := true
+		failingClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&certsv1.Certificate{}).
+			WithIndex(&corev1.Secret{}, secretutil.OwnerUIDIndex, secretutil.IndexOwnerUID).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					if _, isSecret := obj.(*corev1.Secret); isSecret && failing {
+						return errors.New("secrets are forbidden")
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		backoffRecorder := &FakeRecorder{}
+		backoffReconciler := &CertificateReconciler{
+			Client:             failingClient,
+			Scheme:             scheme,
+			Logger:             logger,
+			Recorder:           backoffRecorder,
+			MaxIssuanceBackoff: 15 * time.Second,
+		}
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "failing-cert",
+				Namespace:  "default",
+				Generation: 1,
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{Name: "failing-secret"},
+				DNSNames:  []string{"failing.example.com"},
+				Validity:  "30d",
+			},
+		}
+		err := failingClient.Create(context.TODO(), cert)
+		assert.NoError(t, err)
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "failing-cert", Namespace: "default"}}
+
+		// The failure is recorded and the certificate is retried after the initial backoff
+		result, err := backoffReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, result.RequeueAfter)
+
+		err = failingClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), cert.Status.FailedIssuanceAttempts)
+		assert.NotNil(t, cert.Status.LastFailureTime)
+		issuingCondition := meta.FindStatusCondition(cert.Status.Conditions, certsv1.CertificateConditionIssuing)
+		if assert.NotNil(t, issuingCondition) {
+			assert.Equal(t, metav1.ConditionFalse, issuingCondition.Status)
+			assert.Equal(t, "SecretCreationFailed", issuingCondition.Reason)
+			assert.Contains(t, issuingCondition.Message, "secrets are forbidden")
+		}
+		assert.False(t, meta.IsStatusConditionTrue(cert.Status.Conditions, certsv1.CertificateConditionReady))
+		assert.Equal(t, []string{"SecretCreationFailed"}, backoffRecorder.Events)
+
+		// Reconciles triggered during the backoff do not try again
+		result, err = backoffReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter, time.Duration(0))
+		assert.LessOrEqual(t, result.RequeueAfter, 10*time.Second)
+		err = failingClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), cert.Status.FailedIssuanceAttempts)
+
+		// Once the backoff is over, the same failure doubles the backoff up to its maximum without a new event
+		for _, expected := range []time.Duration{15 * time.Second, 15 * time.Second} {
+			cert.Status.LastFailureTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+			err = failingClient.Status().Update(context.TODO(), cert)
+			assert.NoError(t, err)
+
+			result, err = backoffReconciler.Reconcile(context.TODO(), req)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, result.RequeueAfter)
+			err = failingClient.Get(context.TODO(), req.NamespacedName, cert)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), cert.Status.FailedIssuanceAttempts)
+		assert.Equal(t, []string{"SecretCreationFailed"}, backoffRecorder.Events)
+
+		// A change of the spec is retried right away and restarts the backoff
+		cert.Generation = 2
+		cert.Spec.DNSNames = []string{"failing.example.com", "www.failing.example.com"}
+		err = failingClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		result, err = backoffReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, result.RequeueAfter)
+		err = failingClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), cert.Status.FailedIssuanceAttempts)
+
+		// A successful issuance clears the failures
+		failing = false
+		cert.Status.LastFailureTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+		err = failingClient.Status().Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		_, err = backoffReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Contains(t, backoffRecorder.Events, "SecretCreationSuccessful")
+
+		err = failingClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Zero(t, cert.Status.FailedIssuanceAttempts)
+		assert.Nil(t, cert.Status.LastFailureTime)
+		assert.Nil(t, meta.FindStatusCondition(cert.Status.Conditions, certsv1.CertificateConditionIssuing))
+		assert.True(t, meta.IsStatusConditionTrue(cert.Status.Conditions, certsv1.CertificateConditionReady))
+
+		// A failed migration to a renamed secret is retried after a backoff, the previous secret is already recorded
+		failing = true
+		cert.Generation = 3
+		cert.Spec.SecretRef.Name = "failing-renamed"
+		err = failingClient.Update(context.TODO(), cert)
+		assert.NoError(t, err)
+		result, err = backoffReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, result.RequeueAfter)
+		err = failingClient.Get(context.TODO(), req.NamespacedName, cert)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), cert.Status.FailedIssuanceAttempts)
+		assert.Equal(t, "SecretMigrationFailed", meta.FindStatusCondition(cert.Status.Conditions, certsv1.CertificateConditionIssuing).Reason)
+		assert.Equal(t, []string{"failing-secret"}, previousSecretNames(cert))
+		assert.Contains(t, backoffRecorder.Events, "SecretMigrationFailed")
+
+		assert.Equal(t, time.Hour, issuanceBackoff(20, time.Hour))
+		assert.Equal(t, 40*time.Second, issuanceBackoff(3, time.Hour))
+	})
+
 	t.Run("Secret Deletion", func(t *testing.T) {
 		// Create a sample Certificate CR
 		cert := &certsv1.Certificate{
@@ -213,6 +1957,247 @@ func TestCertificateController(t *testing.T) {
 	})
 }
 
+// BenchmarkSecretCache compares the memory held by the caches of the manager when it caches every secret
+// with the cache restricted by secretutil.CacheOptions, in a cluster dominated by large Helm release secrets.
+// The restricted cache is measured alone and along with the metadata cache of every secret the controllers
+// watch, which is what the manager holds in memory
+func BenchmarkSecretCache(b *testing.B) {
+	config := newSecretServer(b, benchmarkSecrets(200, 50))
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+
+	for _, bc := range []struct {
+		name     string
+		byObject map[client.Object]cache.ByObject
+		metadata bool
+	}{
+		{name: "AllSecrets"},
+		{name: "ManagedSecrets", byObject: map[client.Object]cache.ByObject{&corev1.Secret{}: secretutil.CacheOptions()}},
+		{name: "ManagedSecretsAndMetadata", byObject: map[client.Object]cache.ByObject{&corev1.Secret{}: secretutil.CacheOptions()}, metadata: true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var cached uint64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				caches := map[cache.Cache]client.Object{}
+				secretCache, err := cache.New(config, cache.Options{Mapper: mapper, ByObject: bc.byObject})
+				if err != nil {
+					b.Fatal(err)
+				}
+				caches[secretCache] = &corev1.Secret{}
+				if bc.metadata {
+					opts := secretutil.MetadataCacheOptions(nil)
+					opts.Mapper = mapper
+					metadataCache, err := cache.New(config, opts)
+					if err != nil {
+						b.Fatal(err)
+					}
+					caches[metadataCache] = secretutil.SecretMetadata()
+				}
+				before := heapAlloc()
+				b.StartTimer()
+
+				cacheCtx, cancel := context.WithCancel(context.Background())
+				var informers []cache.Informer
+				for c, obj := range caches {
+					informer, err := c.GetInformer(cacheCtx, obj)
+					if err != nil {
+						b.Fatal(err)
+					}
+					informers = append(informers, informer)
+					go func() { _ = c.Start(cacheCtx) }()
+					if !c.WaitForCacheSync(cacheCtx) {
+						b.Fatal("failed to sync the secret cache")
+					}
+				}
+
+				b.StopTimer()
+				if after := heapAlloc(); after > before {
+					cached += after - before
+				}
+				goruntime.KeepAlive(informers)
+				cancel()
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(cached)/float64(b.N), "cached-bytes/op")
+		})
+	}
+}
+
+// newSecretServer serves the secrets from a minimal API server for the cache to list and watch. The label
+// selector of list requests is honoured like the API server does, watches stay idle until they are closed
+func newSecretServer(b *testing.B, secrets []corev1.Secret) *rest.Config {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// metadata clients ask for the metadata of the secrets only
+		if strings.Contains(r.Header.Get("Accept"), "as=PartialObjectMetadataList") {
+			list := &metav1.PartialObjectMetadataList{
+				TypeMeta: metav1.TypeMeta{APIVersion: "meta.k8s.io/v1", Kind: "PartialObjectMetadataList"},
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+			}
+			for _, secret := range secrets {
+				if selector.Matches(labels.Set(secret.Labels)) {
+					list.Items = append(list.Items, metav1.PartialObjectMetadata{
+						TypeMeta:   metav1.TypeMeta{APIVersion: "meta.k8s.io/v1", Kind: "PartialObjectMetadata"},
+						ObjectMeta: secret.ObjectMeta,
+					})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(list)
+			return
+		}
+
+		list := &corev1.SecretList{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "SecretList"},
+			ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		}
+		for _, secret := range secrets {
+			if selector.Matches(labels.Set(secret.Labels)) {
+				list.Items = append(list.Items, secret)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	}))
+	b.Cleanup(func() {
+		server.CloseClientConnections()
+		server.Close()
+	})
+	return &rest.Config{Host: server.URL}
+}
+
+// benchmarkSecrets returns large unmanaged Helm release secrets along with secrets managed by certaur
+func benchmarkSecrets(releases, managed int) []corev1.Secret {
+	managedFields := []metav1.ManagedFieldsEntry{{
+		Manager:    "certaur",
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:` + strings.Repeat("x", 2048) + `":{}}}`)},
+	}}
+
+	var secrets []corev1.Secret
+	for i := 0; i < releases; i++ {
+		secrets = append(secrets, corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:          fmt.Sprintf("sh.helm.release.v1.app-%d.v1", i),
+				Namespace:     "default",
+				Labels:        map[string]string{"owner": "helm"},
+				ManagedFields: managedFields,
+			},
+			Type: "helm.sh/release.v1",
+			Data: map[string][]byte{"release": make([]byte, 64*1024)},
+		})
+	}
+	for i := 0; i < managed; i++ {
+		secrets = append(secrets, corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:          fmt.Sprintf("managed-secret-%d", i),
+				Namespace:     "default",
+				Labels:        map[string]string{secretutil.ManagedLabel: "true"},
+				ManagedFields: managedFields,
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       make([]byte, 2048),
+				corev1.TLSPrivateKeyKey: make([]byte, 2048),
+			},
+		})
+	}
+	return secrets
+}
+
+// heapAlloc returns the bytes allocated on the heap after a garbage collection
+func heapAlloc() uint64 {
+	var stats goruntime.MemStats
+	goruntime.GC()
+	goruntime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// generateTestCertificate issues a self-signed certificate with an arbitrary validity window
+func generateTestCertificate(t *testing.T, dnsNames []string, notBefore, notAfter time.Time) ([]byte, []byte) {
+	key, err := certificateutil.GeneratePrivateKey(nil)
+	assert.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		DNSNames:              dnsNames,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	assert.NoError(t, err)
+
+	keyPEM, err := certificateutil.EncodePrivateKey(key, certsv1.PKCS1KeyEncoding)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), keyPEM
+}
+
+// generateTestKeyPEM generates a PEM encoded private key unrelated to any certificate
+func generateTestKeyPEM(t *testing.T) []byte {
+	key, err := certificateutil.GeneratePrivateKey(nil)
+	assert.NoError(t, err)
+
+	keyPEM, err := certificateutil.EncodePrivateKey(key, certsv1.PKCS1KeyEncoding)
+	assert.NoError(t, err)
+	return keyPEM
+}
+
+// generateTestCA issues a self-signed CA keypair
+func generateTestCA(t *testing.T) ([]byte, []byte) {
+	key, err := certificateutil.GeneratePrivateKey(nil)
+	assert.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "certaur test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	assert.NoError(t, err)
+
+	keyPEM, err := certificateutil.EncodePrivateKey(key, certsv1.PKCS1KeyEncoding)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), keyPEM
+}
+
+// decodeCertificates parses every certificate of a PEM bundle
+func decodeCertificates(t *testing.T, bundle []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(bundle); block != nil; block, rest = pem.Decode(rest) {
+		c, err := x509.ParseCertificate(block.Bytes)
+		assert.NoError(t, err)
+		certs = append(certs, c)
+	}
+	return certs
+}
+
+// mustExtractCA parses the CA keypair stored in the secret
+func mustExtractCA(t *testing.T, secret *corev1.Secret) *certificateutil.CA {
+	ca, err := certificateutil.ExtractCAData(*secret)
+	assert.NoError(t, err)
+	return ca
+}
+
 type FakeRecorder struct {
 	Events []string
 }
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// SecretRetentionFinalizer holds the deletion of certificates with the Retain secret deletion policy
+// until their secret has been orphaned
+const SecretRetentionFinalizer = "certs.k8c.io/secret-retention"
+
+// reconcileDeletionPolicy adds the retention finalizer to certificates retaining their secret and removes
+// it from the others. Once a certificate holding the finalizer is deleted, its secret is orphaned before
+// the finalizer is removed. It reports whether the certificate is being deleted
+func (r *CertificateReconciler) reconcileDeletionPolicy(ctx context.Context, cert *certsv1.Certificate) (bool, error) {
+	retain := secretutil.SecretDeletionPolicy(cert) == certsv1.RetainSecretDeletionPolicy
+
+	if !cert.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(cert, SecretRetentionFinalizer) {
+			return true, nil
+		}
+		if retain {
+			if err := secretutil.OrphanSecret(ctx, r.Client, cert); err != nil {
+				r.RecordAndLogError(cert, "SecretRetentionFailed", fmt.Sprintf("Failed to retain Secret %s: %v", cert.Spec.SecretRef.Name, err), err)
+				return true, err
+			}
+			r.RecordAndLogInfo(cert, "SecretRetained", fmt.Sprintf("Retained Secret %s after the deletion of the certificate", cert.Spec.SecretRef.Name))
+		}
+		controllerutil.RemoveFinalizer(cert, SecretRetentionFinalizer)
+		return true, r.Update(ctx, cert)
+	}
+
+	if retain == controllerutil.ContainsFinalizer(cert, SecretRetentionFinalizer) {
+		return false, nil
+	}
+	if retain {
+		controllerutil.AddFinalizer(cert, SecretRetentionFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(cert, SecretRetentionFinalizer)
+	}
+	return false, r.Update(ctx, cert)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	configv1alpha1 "github.com/AKI-25/certaur/pkg/config/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// initialIssuanceBackoff is the delay after the first failed attempt to issue a certificate,
+// it doubles with every consecutive failure up to the maximum backoff of the reconciler
+const initialIssuanceBackoff = 10 * time.Second
+
+// issuanceBackoff returns the delay before the next attempt to issue a certificate after the given
+// number of consecutive failures, capped at maxBackoff
+func issuanceBackoff(attempts int32, maxBackoff time.Duration) time.Duration {
+	backoff := initialIssuanceBackoff
+	for i := int32(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+func (r *CertificateReconciler) maxIssuanceBackoff() time.Duration {
+	if r.MaxIssuanceBackoff == 0 {
+		return configv1alpha1.DefaultMaxIssuanceBackoff
+	}
+	return r.MaxIssuanceBackoff
+}
+
+// issuanceBackoffRemaining returns how long the certificate still has to wait before the next attempt
+// to issue it. Certificates whose spec changed since their last failure are retried right away
+func (r *CertificateReconciler) issuanceBackoffRemaining(cert *certsv1.Certificate) time.Duration {
+	status := &cert.Status
+	if status.FailedIssuanceAttempts == 0 || status.LastFailureTime == nil {
+		return 0
+	}
+	issuing := meta.FindStatusCondition(status.Conditions, certsv1.CertificateConditionIssuing)
+	if issuing == nil || issuing.ObservedGeneration != cert.Generation {
+		return 0
+	}
+	return time.Until(status.LastFailureTime.Add(issuanceBackoff(status.FailedIssuanceAttempts, r.maxIssuanceBackoff())))
+}
+
+// markIssuanceFailed records a failed attempt to issue the certificate in its status and requeues it once
+// the backoff is over. The event is only recorded when the failure differs from the previous one, so that
+// a broken certificate does not flood events, and the error is not returned so that the default retries
+// of controller-runtime do not bypass the backoff
+func (r *CertificateReconciler) markIssuanceFailed(ctx context.Context, cert *certsv1.Certificate, reason, message string, err error) (ctrl.Result, error) {
+	previous := meta.FindStatusCondition(cert.Status.Conditions, certsv1.CertificateConditionIssuing)
+	repeated := previous != nil && previous.Reason == reason && previous.Message == err.Error()
+
+	// a change of the spec restarts the backoff
+	if previous == nil || previous.ObservedGeneration != cert.Generation {
+		cert.Status.FailedIssuanceAttempts = 0
+	}
+	cert.Status.FailedIssuanceAttempts++
+	cert.Status.LastFailureTime = &metav1.Time{Time: time.Now()}
+	meta.SetStatusCondition(&cert.Status.Conditions, metav1.Condition{
+		Type:               certsv1.CertificateConditionIssuing,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            err.Error(),
+		ObservedGeneration: cert.Generation,
+	})
+
+	backoff := issuanceBackoff(cert.Status.FailedIssuanceAttempts, r.maxIssuanceBackoff())
+	r.Logger.Error(err, message, "Attempts", cert.Status.FailedIssuanceAttempts, "Backoff", backoff)
+	if !repeated {
+		r.Recorder.Event(cert, corev1.EventTypeWarning, reason, fmt.Sprintf("%s: %v, retrying in %s", message, err, backoff))
+	}
+
+	// markNotReady persists the status and hands the error back, which is already reported above
+	_ = r.markNotReady(ctx, cert, reason, err)
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
+
+// dependencyChanged lets the certificates enqueued by a change of one of their dependencies skip their
+// issuance backoff on their next reconcile, so that they do not wait up to the maximum backoff once the
+// issuer, CA secret or password secret that made their issuance fail is fixed
+func (r *CertificateReconciler) dependencyChanged(requests []reconcile.Request) []reconcile.Request {
+	for _, req := range requests {
+		r.changedDependencies.Store(req.NamespacedName, struct{}{})
+	}
+	return requests
+}
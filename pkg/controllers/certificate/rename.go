@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// recordPreviousSecret records the previous secret of the certificate in its status to be deleted once
+// the grace period is over. It is recorded before the new secret is created, so that it is not left
+// behind when the reconcile is interrupted, and keeps its deletion time when it is already recorded
+func (r *CertificateReconciler) recordPreviousSecret(ctx context.Context, cert *certsv1.Certificate, name string) error {
+	if previousSecretIndex(cert, name) >= 0 {
+		return nil
+	}
+	cert.Status.PreviousSecrets = append(cert.Status.PreviousSecrets, certsv1.PreviousSecret{
+		Name:         name,
+		DeletionTime: metav1.Time{Time: time.Now().Add(r.SecretRenameGracePeriod)},
+	})
+	return r.Status().Update(ctx, cert)
+}
+
+// migrateSecret moves the certificate from its previous secret to the secret referenced by its secretRef.
+// The keypair of the previous secret is copied when it still matches the certificate, in which case the
+// PEM encoded certificate is returned, nil is returned when a new certificate has to be issued instead
+func (r *CertificateReconciler) migrateSecret(ctx context.Context, cert *certsv1.Certificate, previous *corev1.Secret, opts certificateutil.IssueOptions) ([]byte, error) {
+	secret, err := secretutil.MigrateSecret(ctx, r.Client, cert, previous, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret == nil {
+		r.RecordAndLogInfo(cert, "SecretMigrationSkipped", fmt.Sprintf("Keypair of Secret %s does not match the certificate, issuing a new one into Secret %s", previous.Name, cert.Spec.SecretRef.Name))
+		return nil, nil
+	}
+	message := fmt.Sprintf("Copied the keypair of Secret %s to Secret %s", previous.Name, secret.Name)
+	if i := previousSecretIndex(cert, previous.Name); i >= 0 {
+		message += fmt.Sprintf(", Secret %s is kept until %s", previous.Name, cert.Status.PreviousSecrets[i].DeletionTime.Format(time.RFC3339))
+	}
+	r.RecordAndLogInfo(cert, "SecretMigrated", message)
+	return secret.Data[secretutil.SecretKeys(cert).Certificate], nil
+}
+
+// previousSecretIndex returns the index of the previous secret with the given name in the status
+// of the certificate, -1 when it is not recorded
+func previousSecretIndex(cert *certsv1.Certificate, name string) int {
+	return slices.IndexFunc(cert.Status.PreviousSecrets, func(previous certsv1.PreviousSecret) bool {
+		return previous.Name == name
+	})
+}
+
+// previousSecretNames returns the names of the previous secrets recorded in the status of the certificate
+func previousSecretNames(cert *certsv1.Certificate) []string {
+	names := make([]string, 0, len(cert.Status.PreviousSecrets))
+	for _, previous := range cert.Status.PreviousSecrets {
+		names = append(names, previous.Name)
+	}
+	return names
+}
+
+// reconcilePreviousSecrets deletes the previous secrets of the certificate whose grace period is over
+func (r *CertificateReconciler) reconcilePreviousSecrets(ctx context.Context, cert *certsv1.Certificate) error {
+	var remaining []certsv1.PreviousSecret
+	for _, previous := range cert.Status.PreviousSecrets {
+		if time.Now().Before(previous.DeletionTime.Time) {
+			remaining = append(remaining, previous)
+			continue
+		}
+		if err := r.deletePreviousSecret(ctx, cert, previous.Name); err != nil {
+			return err
+		}
+	}
+	if len(remaining) == len(cert.Status.PreviousSecrets) {
+		return nil
+	}
+
+	cert.Status.PreviousSecrets = remaining
+	return r.Status().Update(ctx, cert)
+}
+
+// deletePreviousSecret deletes a previous secret owned by the certificate, the secret is left untouched
+// when the secretRef has been renamed back to it
+func (r *CertificateReconciler) deletePreviousSecret(ctx context.Context, cert *certsv1.Certificate, name string) error {
+	if name == cert.Spec.SecretRef.Name {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: cert.Namespace}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !secretutil.IsOwnerReference(cert, secret) {
+		return nil
+	}
+	if err := r.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	r.RecordAndLogInfo(cert, "PreviousSecretDeleted", fmt.Sprintf("Deleted previous Secret %s after the grace period", name))
+	return nil
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateStatus records the issued certificate in the status of the Certificate and marks it as ready,
+// the revision is only incremented when a new certificate has been issued. Previous issuance failures
+// are cleared
+func (r *CertificateReconciler) updateStatus(ctx context.Context, cert *certsv1.Certificate, crtPEM []byte, issued bool) error {
+	parsedCert, err := certificateutil.ParseCertificate(crtPEM)
+	if err != nil {
+		return err
+	}
+
+	status := cert.Status.DeepCopy()
+	status.NotBefore = &metav1.Time{Time: parsedCert.NotBefore}
+	status.NotAfter = &metav1.Time{Time: parsedCert.NotAfter}
+	status.RenewalTime = &metav1.Time{Time: certificateutil.RenewalTime(parsedCert.NotBefore, parsedCert.NotAfter, &cert.Spec)}
+	status.SerialNumber = fmt.Sprintf("%X", parsedCert.SerialNumber)
+	status.FingerprintSHA256 = certificateutil.Fingerprint(parsedCert)
+	if issued || status.Revision == 0 {
+		status.Revision++
+	}
+	status.ObservedGeneration = cert.Generation
+	status.SecretName = cert.Spec.SecretRef.Name
+	status.FailedIssuanceAttempts = 0
+	status.LastFailureTime = nil
+	meta.RemoveStatusCondition(&status.Conditions, certsv1.CertificateConditionIssuing)
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               certsv1.CertificateConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Ready",
+		Message:            "Certificate is up to date and has not expired",
+		ObservedGeneration: cert.Generation,
+	})
+
+	if equality.Semantic.DeepEqual(&cert.Status, status) {
+		return nil
+	}
+	cert.Status = *status
+	return r.Status().Update(ctx, cert)
+}
+
+// markNotReady records the failure in the Ready condition of the Certificate,
+// the original error is returned so that the request gets retried
+func (r *CertificateReconciler) markNotReady(ctx context.Context, cert *certsv1.Certificate, reason string, err error) error {
+	cert.Status.ObservedGeneration = cert.Generation
+	meta.SetStatusCondition(&cert.Status.Conditions, metav1.Condition{
+		Type:               certsv1.CertificateConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            err.Error(),
+		ObservedGeneration: cert.Generation,
+	})
+
+	if updateErr := r.Status().Update(ctx, cert); updateErr != nil {
+		r.Logger.Error(updateErr, "failed to update Certificate status")
+	}
+	return err
+}
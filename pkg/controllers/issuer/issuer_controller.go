@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	issuerutil "github.com/AKI-25/certaur/pkg/util/issuer"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// interval at which an issuer that is not ready is checked again
+const notReadyRequeueInterval = time.Minute
+
+// IssuerReconciler reconciles an Issuer object
+type IssuerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Logger   logr.Logger
+	Recorder record.EventRecorder
+	// Backdate is how long the NotBefore of generated root CAs is set in the past
+	Backdate time.Duration
+	// SecretMetadataCache holds the metadata of every secret, it is watched for the CA secrets,
+	// which are missing from the cache of the manager unless they have been generated by certaur
+	SecretMetadataCache cache.Cache
+}
+
+func (r *IssuerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var issuer certsv1.Issuer
+	if err := r.Get(ctx, req.NamespacedName, &issuer); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return reconcileIssuer(ctx, r.Client, r.Logger, r.Recorder, &issuer, "", r.Backdate)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IssuerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &certsv1.Issuer{}, issuerutil.CASecretNameIndex, issuerutil.IndexCASecretName); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&certsv1.Issuer{}).
+		WatchesRawSource(source.Kind(r.SecretMetadataCache, client.Object(secretutil.SecretMetadata()), handler.EnqueueRequestsFromMapFunc(r.issuersForSecret))).
+		Complete(r)
+}
+
+// issuersForSecret enqueues the issuers whose CA keypair is stored in the secret, so that a deleted
+// root CA secret is generated again and a rotated CA is verified. The issuers are listed in every
+// namespace, as the secrets of the cluster resource namespace are watched even when its issuers are not
+func (r *IssuerReconciler) issuersForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var issuers certsv1.IssuerList
+	if err := r.List(ctx, &issuers, client.MatchingFields{issuerutil.CASecretNameIndex: secret.GetName()}); err != nil {
+		r.Logger.Error(err, "Failed to list issuers of secret", "Secret", secret.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, issuer := range issuers.Items {
+		if issuer.Namespace == secret.GetNamespace() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&issuer)})
+		}
+	}
+	return requests
+}
+
+// ClusterIssuerReconciler reconciles a ClusterIssuer object
+type ClusterIssuerReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Logger   logr.Logger
+	Recorder record.EventRecorder
+	// ClusterResourceNamespace is the namespace holding the CA secrets of ClusterIssuers
+	ClusterResourceNamespace string
+	// Backdate is how long the NotBefore of generated root CAs is set in the past
+	Backdate time.Duration
+	// SecretMetadataCache holds the metadata of every secret, it is watched for the CA secrets,
+	// which are missing from the cache of the manager unless they have been generated by certaur
+	SecretMetadataCache cache.Cache
+}
+
+func (r *ClusterIssuerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var issuer certsv1.ClusterIssuer
+	if err := r.Get(ctx, req.NamespacedName, &issuer); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return reconcileIssuer(ctx, r.Client, r.Logger, r.Recorder, &issuer, r.ClusterResourceNamespace, r.Backdate)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterIssuerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &certsv1.ClusterIssuer{}, issuerutil.CASecretNameIndex, issuerutil.IndexCASecretName); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&certsv1.ClusterIssuer{}).
+		WatchesRawSource(source.Kind(r.SecretMetadataCache, client.Object(secretutil.SecretMetadata()), handler.EnqueueRequestsFromMapFunc(r.clusterIssuersForSecret))).
+		Complete(r)
+}
+
+// clusterIssuersForSecret enqueues the ClusterIssuers whose CA keypair is stored in the secret,
+// only secrets of the cluster resource namespace are considered
+func (r *ClusterIssuerReconciler) clusterIssuersForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	if secret.GetNamespace() != issuerutil.SecretNamespace(&certsv1.ClusterIssuer{}, r.ClusterResourceNamespace) {
+		return nil
+	}
+
+	var issuers certsv1.ClusterIssuerList
+	if err := r.List(ctx, &issuers, client.MatchingFields{issuerutil.CASecretNameIndex: secret.GetName()}); err != nil {
+		r.Logger.Error(err, "Failed to list ClusterIssuers of secret", "Secret", secret.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(issuers.Items))
+	for _, issuer := range issuers.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&issuer)})
+	}
+	return requests
+}
+
+// reconcileIssuer checks that the issuer is able to sign certificates and records it in its Ready condition
+func reconcileIssuer(ctx context.Context, c client.Client, logger logr.Logger, recorder record.EventRecorder, issuer certsv1.GenericIssuer, clusterResourceNamespace string, backdate time.Duration) (ctrl.Result, error) {
+	condition := metav1.Condition{
+		Type:               certsv1.IssuerConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: issuer.GetGeneration(),
+	}
+
+	var caExpiry time.Time
+	switch spec := issuer.GetSpec(); {
+	case spec.SelfSigned != nil:
+		condition.Reason = "SelfSigned"
+		condition.Message = "Issuer signs certificates with their own private key"
+	case spec.CA != nil, spec.RootCA != nil:
+		generated, err := issuerutil.BootstrapRootCA(ctx, c, issuer, clusterResourceNamespace, certificateutil.IssueOptions{Backdate: backdate})
+		if err != nil {
+			logger.Error(err, "Failed to bootstrap root CA", "Issuer", issuer.GetName())
+			recorder.Event(issuer, corev1.EventTypeWarning, "CAGenerationFailed", err.Error())
+			return ctrl.Result{}, err
+		} else if generated {
+			logger.Info("Generated root CA", "Issuer", issuer.GetName(), "Secret", spec.RootCA.SecretName)
+			recorder.Event(issuer, corev1.EventTypeNormal, "CAGenerated", "Generated root CA keypair in secret "+spec.RootCA.SecretName)
+		}
+
+		ca, err := issuerutil.LoadCA(ctx, c, issuer, clusterResourceNamespace)
+		if err != nil {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "KeyPairInvalid"
+			if apierrors.IsNotFound(err) {
+				condition.Reason = "SecretNotFound"
+			} else if errors.Is(err, issuerutil.ErrCAExpired) {
+				condition.Reason = "CAExpired"
+			}
+			condition.Message = err.Error()
+		} else {
+			// The issuer is reconciled again when its CA expires to become not ready
+			caExpiry = ca.Certificate.NotAfter
+			condition.Reason = "KeyPairVerified"
+			condition.Message = "Issuer signs certificates with the CA keypair of secret " + issuerutil.CASecretName(issuer)
+		}
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidConfig"
+		condition.Message = "exactly one issuer type must be set"
+	}
+
+	status := issuer.GetStatus()
+	if meta.SetStatusCondition(&status.Conditions, condition) {
+		eventType := corev1.EventTypeNormal
+		if condition.Status != metav1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+		logger.Info("Issuer readiness changed", "Issuer", issuer.GetName(), "Ready", condition.Status, "Reason", condition.Reason)
+		recorder.Event(issuer, eventType, condition.Reason, condition.Message)
+
+		if err := c.Status().Update(ctx, issuer); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if condition.Status != metav1.ConditionTrue {
+		return ctrl.Result{RequeueAfter: notReadyRequeueInterval}, nil
+	}
+	if !caExpiry.IsZero() {
+		return ctrl.Result{RequeueAfter: time.Until(caExpiry)}, nil
+	}
+	return ctrl.Result{}, nil
+}
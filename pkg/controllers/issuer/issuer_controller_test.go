@@ -0,0 +1,272 @@
+package controller
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	issuerutil "github.com/AKI-25/certaur/pkg/util/issuer"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestIssuerController(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = certsv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&certsv1.Issuer{}, &certsv1.ClusterIssuer{}).
+		WithIndex(&certsv1.Issuer{}, issuerutil.CASecretNameIndex, issuerutil.IndexCASecretName).
+		WithIndex(&certsv1.ClusterIssuer{}, issuerutil.CASecretNameIndex, issuerutil.IndexCASecretName).
+		Build()
+	logger := zap.New(zap.UseDevMode(true))
+
+	issuerReconciler := &IssuerReconciler{
+		Client:   fakeClient,
+		Scheme:   scheme,
+		Logger:   logger,
+		Recorder: record.NewFakeRecorder(10),
+		Backdate: 5 * time.Minute,
+	}
+	clusterIssuerReconciler := &ClusterIssuerReconciler{
+		Client:                   fakeClient,
+		Scheme:                   scheme,
+		Logger:                   logger,
+		Recorder:                 record.NewFakeRecorder(10),
+		ClusterResourceNamespace: "certaur-system",
+	}
+
+	t.Run("SelfSigned ClusterIssuer", func(t *testing.T) {
+		issuer := &certsv1.ClusterIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "selfsigned"},
+			Spec:       certsv1.IssuerSpec{SelfSigned: &certsv1.SelfSignedIssuer{}},
+		}
+		err := fakeClient.Create(context.TODO(), issuer)
+		assert.NoError(t, err)
+
+		_, err = clusterIssuerReconciler.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "selfsigned"}})
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "selfsigned"}, issuer)
+		assert.NoError(t, err)
+		assert.True(t, meta.IsStatusConditionTrue(issuer.Status.Conditions, certsv1.IssuerConditionReady))
+	})
+
+	t.Run("CA Issuer", func(t *testing.T) {
+		issuer := &certsv1.Issuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "default"},
+			Spec:       certsv1.IssuerSpec{CA: &certsv1.CAIssuer{SecretName: "ca-key-pair"}},
+		}
+		err := fakeClient.Create(context.TODO(), issuer)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ca", Namespace: "default"}}
+
+		// The issuer is not ready as long as its CA secret is missing
+		result, err := issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, notReadyRequeueInterval, result.RequeueAfter)
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, issuer)
+		assert.NoError(t, err)
+		readyCondition := meta.FindStatusCondition(issuer.Status.Conditions, certsv1.IssuerConditionReady)
+		assert.Equal(t, metav1.ConditionFalse, readyCondition.Status)
+		assert.Equal(t, "SecretNotFound", readyCondition.Reason)
+
+		// A secret holding a certificate that is not a CA is rejected
+		leafKey, err := certificateutil.GeneratePrivateKey(nil)
+		assert.NoError(t, err)
+		leafCertPEM, leafKeyPEM := generateTestKeyPair(t, leafKey, false, time.Now().Add(24*time.Hour))
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-key-pair", Namespace: "default"},
+			Data:       map[string][]byte{"tls.crt": leafCertPEM, "tls.key": leafKeyPEM},
+			Type:       corev1.SecretTypeTLS,
+		}
+		err = fakeClient.Create(context.TODO(), secret)
+		assert.NoError(t, err)
+
+		_, err = issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, issuer)
+		assert.NoError(t, err)
+		readyCondition = meta.FindStatusCondition(issuer.Status.Conditions, certsv1.IssuerConditionReady)
+		assert.Equal(t, "KeyPairInvalid", readyCondition.Reason)
+
+		// The issuer becomes ready once the secret holds a valid CA keypair
+		caKey, err := certificateutil.GeneratePrivateKey(nil)
+		assert.NoError(t, err)
+		caCertPEM, caKeyPEM := generateTestKeyPair(t, caKey, true, time.Now().Add(24*time.Hour))
+		secret.Data = map[string][]byte{"tls.crt": caCertPEM, "tls.key": caKeyPEM}
+		err = fakeClient.Update(context.TODO(), secret)
+		assert.NoError(t, err)
+
+		// it is reconciled again when the CA expires
+		result, err = issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		assert.InDelta(t, 24*time.Hour, result.RequeueAfter, float64(time.Minute))
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, issuer)
+		assert.NoError(t, err)
+		readyCondition = meta.FindStatusCondition(issuer.Status.Conditions, certsv1.IssuerConditionReady)
+		assert.Equal(t, metav1.ConditionTrue, readyCondition.Status)
+		assert.Equal(t, "KeyPairVerified", readyCondition.Reason)
+
+		// An expired CA makes the issuer not ready
+		expiredCertPEM, expiredKeyPEM := generateTestKeyPair(t, caKey, true, time.Now().Add(-time.Hour))
+		secret.Data = map[string][]byte{"tls.crt": expiredCertPEM, "tls.key": expiredKeyPEM}
+		err = fakeClient.Update(context.TODO(), secret)
+		assert.NoError(t, err)
+		_, err = issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, issuer)
+		assert.NoError(t, err)
+		readyCondition = meta.FindStatusCondition(issuer.Status.Conditions, certsv1.IssuerConditionReady)
+		assert.Equal(t, metav1.ConditionFalse, readyCondition.Status)
+		assert.Equal(t, "CAExpired", readyCondition.Reason)
+
+		secret.Data = map[string][]byte{"tls.crt": caCertPEM, "tls.key": caKeyPEM}
+		err = fakeClient.Update(context.TODO(), secret)
+		assert.NoError(t, err)
+		_, err = issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// The CA secret is left unlabelled and its changes still enqueue the issuer
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "ca-key-pair", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		assert.NotContains(t, secret.Labels, secretutil.ManagedLabel)
+		assert.Equal(t, []reconcile.Request{req}, issuerReconciler.issuersForSecret(context.TODO(), secret))
+		// A secret of the same name in another namespace does not belong to the issuer
+		other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ca-key-pair", Namespace: "certaur-system"}}
+		assert.Empty(t, issuerReconciler.issuersForSecret(context.TODO(), other))
+	})
+
+	t.Run("Root CA Issuer", func(t *testing.T) {
+		maxPathLen := int32(1)
+		issuer := &certsv1.Issuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "root-ca", Namespace: "default"},
+			Spec: certsv1.IssuerSpec{RootCA: &certsv1.RootCAIssuer{
+				SecretName: "root-ca-key-pair",
+				Validity:   "30d",
+				Subject:    &certsv1.X509Subject{Organizations: []string{"certaur"}},
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.ECDSAKeyAlgorithm, Size: 384},
+				MaxPathLen: &maxPathLen,
+			}},
+		}
+		err := fakeClient.Create(context.TODO(), issuer)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "root-ca", Namespace: "default"}}
+		_, err = issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		err = fakeClient.Get(context.TODO(), req.NamespacedName, issuer)
+		assert.NoError(t, err)
+		readyCondition := meta.FindStatusCondition(issuer.Status.Conditions, certsv1.IssuerConditionReady)
+		assert.Equal(t, metav1.ConditionTrue, readyCondition.Status)
+		assert.Equal(t, "KeyPairVerified", readyCondition.Reason)
+
+		// The generated CA must follow the spec of the issuer
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "root-ca-key-pair", Namespace: "default"}, secret)
+		assert.NoError(t, err)
+		ca, err := certificateutil.ExtractCAData(*secret)
+		assert.NoError(t, err)
+		assert.True(t, ca.Certificate.IsCA)
+		assert.Equal(t, 1, ca.Certificate.MaxPathLen)
+		assert.NotZero(t, ca.Certificate.KeyUsage&x509.KeyUsageCertSign)
+		assert.Equal(t, "root-ca", ca.Certificate.Subject.CommonName)
+		assert.Equal(t, []string{"certaur"}, ca.Certificate.Subject.Organization)
+		assert.Equal(t, 30*24*time.Hour, ca.Certificate.NotAfter.Sub(ca.Certificate.NotBefore).Round(time.Hour))
+		assert.Equal(t, x509.ECDSA, ca.Certificate.PublicKeyAlgorithm)
+		assert.InDelta(t, float64(5*time.Minute), float64(time.Since(ca.Certificate.NotBefore)), float64(time.Minute))
+
+		// The CA is persisted and not regenerated on subsequent reconciliations
+		_, err = issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		regenerated := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "root-ca-key-pair", Namespace: "default"}, regenerated)
+		assert.NoError(t, err)
+		assert.Equal(t, secret.Data["tls.crt"], regenerated.Data["tls.crt"])
+		assert.Equal(t, "true", regenerated.Labels[secretutil.ManagedLabel])
+
+		// Deleting the secret enqueues the issuer, which rotates the CA
+		err = fakeClient.Delete(context.TODO(), regenerated)
+		assert.NoError(t, err)
+		assert.Equal(t, []reconcile.Request{req}, issuerReconciler.issuersForSecret(context.TODO(), regenerated))
+		_, err = issuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+		rotated := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "root-ca-key-pair", Namespace: "default"}, rotated)
+		assert.NoError(t, err)
+		assert.NotEqual(t, secret.Data["tls.crt"], rotated.Data["tls.crt"])
+	})
+
+	t.Run("Root CA ClusterIssuer", func(t *testing.T) {
+		issuer := &certsv1.ClusterIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: "root-ca"},
+			Spec: certsv1.IssuerSpec{RootCA: &certsv1.RootCAIssuer{
+				SecretName: "root-ca-key-pair",
+				Duration:   &metav1.Duration{Duration: 90 * time.Minute},
+			}},
+		}
+		err := fakeClient.Create(context.TODO(), issuer)
+		assert.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "root-ca"}}
+		_, err = clusterIssuerReconciler.Reconcile(context.TODO(), req)
+		assert.NoError(t, err)
+
+		// Only the secret of the cluster resource namespace belongs to the ClusterIssuer
+		secret := &corev1.Secret{}
+		err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "root-ca-key-pair", Namespace: "certaur-system"}, secret)
+		assert.NoError(t, err)
+		// The duration of the CA accepts the Go duration format of certificates
+		ca, err := certificateutil.ExtractCAData(*secret)
+		assert.NoError(t, err)
+		assert.Equal(t, 90*time.Minute, ca.Certificate.NotAfter.Sub(ca.Certificate.NotBefore))
+		assert.Equal(t, []reconcile.Request{req}, clusterIssuerReconciler.clusterIssuersForSecret(context.TODO(), secret))
+		namespaced := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "root-ca-key-pair", Namespace: "default"}}
+		assert.Empty(t, clusterIssuerReconciler.clusterIssuersForSecret(context.TODO(), namespaced))
+	})
+}
+
+// generateTestKeyPair issues a self-signed certificate for key, marked as a CA when isCA is set
+func generateTestKeyPair(t *testing.T, key crypto.Signer, isCA bool, notAfter time.Time) ([]byte, []byte) {
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "certaur test"},
+		NotBefore:             notAfter.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	assert.NoError(t, err)
+
+	keyPEM, err := certificateutil.EncodePrivateKey(key, certsv1.PKCS1KeyEncoding)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), keyPEM
+}
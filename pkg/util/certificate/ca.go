@@ -0,0 +1,183 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultCAValidity is the validity of root CA certificates that do not specify one
+const DefaultCAValidity = "3650d"
+
+// CA holds the keypair signing the certificates of an issuer
+type CA struct {
+	// Certificate is the parsed CA certificate
+	Certificate *x509.Certificate
+	// PrivateKey is the private key of the CA certificate
+	PrivateKey crypto.Signer
+	// CertificatePEM is the PEM encoded CA certificate followed by the chain it has been
+	// issued by, appended to the certificates signed by the CA
+	CertificatePEM []byte
+}
+
+// ParseCA parses a PEM encoded CA keypair and checks that it is able to sign certificates
+func ParseCA(certPEM, keyPEM []byte) (*CA, error) {
+	caCert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !caCert.IsCA || caCert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, errors.New("certificate is not a CA certificate")
+	}
+
+	caKey, err := ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA private key: %v", err)
+	}
+
+	ok, err := CheckCertKey(caCert, caKey)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("CA private key does not match the CA certificate")
+	}
+
+	return &CA{Certificate: caCert, PrivateKey: caKey, CertificatePEM: certPEM}, nil
+}
+
+// ClampNotAfter cuts the NotAfter of a certificate signed by the CA short at the NotAfter of the CA,
+// so that the certificate does not outlive the CA. It is returned as is for a nil CA
+func (ca *CA) ClampNotAfter(notAfter time.Time) time.Time {
+	if ca == nil || !notAfter.After(ca.Certificate.NotAfter) {
+		return notAfter
+	}
+	return ca.Certificate.NotAfter
+}
+
+// Clamped reports whether the NotAfter of a certificate signed by the CA has been cut short at the NotAfter of the CA
+func (ca *CA) Clamped(notAfter time.Time) bool {
+	return ca != nil && notAfter.Equal(ca.Certificate.NotAfter)
+}
+
+// ExtractCAData parses the CA keypair stored in the tls.crt and tls.key fields of the secret
+func ExtractCAData(secret corev1.Secret) (*CA, error) {
+	certData, exists := secret.Data["tls.crt"]
+	if !exists {
+		return nil, errors.New("secret does not contain tls.crt field")
+	}
+	keyData, exists := secret.Data["tls.key"]
+	if !exists {
+		return nil, errors.New("secret does not contain tls.key field")
+	}
+	return ParseCA(certData, keyData)
+}
+
+// RootCertificatePEM returns the last certificate of the CA chain, written to ca.crt
+func (ca *CA) RootCertificatePEM() []byte {
+	var root *pem.Block
+	for rest := ca.CertificatePEM; ; {
+		block, next := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		root, rest = block, next
+	}
+	if root == nil {
+		return nil
+	}
+	return pem.EncodeToMemory(root)
+}
+
+// CheckCertIssuer reports whether the certificate has been signed by the CA, or is
+// self-signed when the CA is nil, and whether the chain following the certificate in
+// tls.crt and the ca.crt data are the expected ones
+func CheckCertIssuer(cert *x509.Certificate, crtData []byte, ca *CA, caData []byte) bool {
+	_, chain := pem.Decode(crtData)
+	if ca == nil {
+		return len(caData) == 0 && len(bytes.TrimSpace(chain)) == 0 &&
+			cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+	}
+	return bytes.Equal(caData, ca.RootCertificatePEM()) &&
+		bytes.Equal(bytes.TrimSpace(chain), bytes.TrimSpace(ca.CertificatePEM)) &&
+		cert.CheckSignatureFrom(ca.Certificate) == nil
+}
+
+// RootCADuration returns the lifetime of the root CA requested by the spec, parsed like the lifetime of
+// certificates. It defaults to DefaultCAValidity when neither validity nor duration are set
+func RootCADuration(spec *certsv1.RootCAIssuer) (time.Duration, error) {
+	validity := spec.Validity
+	if validity == "" && spec.Duration == nil {
+		validity = DefaultCAValidity
+	}
+	duration, err := LifetimeDuration(validity, spec.Duration)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid CA duration %s, it must be positive", duration)
+	}
+	return duration, nil
+}
+
+// GenerateRootCA generates a self-signed root CA certificate and key based on the provided
+// spec and issue options, the common name defaults to name when the spec does not set one
+func GenerateRootCA(name string, spec *certsv1.RootCAIssuer, opts IssueOptions) ([]byte, []byte, error) {
+	priv, err := GeneratePrivateKey(spec.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	duration, err := RootCADuration(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subject := subjectName(spec.Subject)
+	if subject.CommonName == "" {
+		subject.CommonName = name
+	}
+
+	maxPathLen := 0
+	if spec.MaxPathLen != nil {
+		maxPathLen = int(*spec.MaxPathLen)
+	}
+
+	serialNumber, err := SerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               subject,
+		NotBefore:             now.Add(-opts.Backdate),
+		NotAfter:              now.Add(duration),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            maxPathLen,
+		MaxPathLenZero:        maxPathLen == 0,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, priv.Public(), priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM, err := EncodePrivateKey(priv, KeyEncoding(spec.PrivateKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return certPEM, keyPEM, nil
+}
@@ -1,56 +1,322 @@
 package certificate
 
 import (
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
-	corev1 "k8s.io/api/core/v1"
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	"github.com/AKI-25/certaur/pkg/util/keystore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// generate a TLS certificate and key based on the provided DNS and validity
-func GenerateTLSCertificate(dns string, validity string) ([]byte, []byte, error) {
-	// Generate RSA private key
-	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+// DefaultBackdate is how long the NotBefore of certificates is set in the past by default,
+// so that clients with a clock running slightly behind already accept new certificates
+const DefaultBackdate = time.Minute
+
+// certificate times only have a precision of one second
+const validityTolerance = time.Second
+
+// serialNumberLimit bounds the random serial numbers of certificates to 128 bits
+var serialNumberLimit = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// SerialNumber returns a random 128-bit serial number, so that certificates signed by the
+// same CA at the same time still get distinct and unpredictable serial numbers
+func SerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serialNumber, nil
+}
+
+// IssueOptions controls how GenerateTLSCertificate issues a certificate
+type IssueOptions struct {
+	// CA signs the certificate, which is self-signed when it is nil
+	CA *CA
+	// PrivateKey is reused for the certificate when set, a new key is generated otherwise
+	PrivateKey crypto.Signer
+	// Backdate moves the NotBefore of the certificate in the past to absorb clock skew,
+	// the NotAfter of the certificate is not affected
+	Backdate time.Duration
+	// KeystorePasswords protect the keystores written next to the certificate
+	KeystorePasswords keystore.Passwords
+}
+
+// generate a TLS certificate and key based on the provided certificate spec and issue options
+func GenerateTLSCertificate(spec *certsv1.CertificateSpec, opts IssueOptions) ([]byte, []byte, error) {
+	// Generate the private key unless an existing one is reused
+	priv := opts.PrivateKey
+	if priv == nil {
+		var err error
+		priv, err = GeneratePrivateKey(spec.PrivateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	duration, err := CertificateDuration(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ipAddresses, err := IPAddresses(spec)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	validityInt, err := extractDaysOfValidity(validity)
+	uris, err := URIs(spec)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Create a self-signed certificate
+	usage, extUsages, err := KeyUsages(spec, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err := SerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Both NotBefore and NotAfter derive from a single timestamp, the NotAfter of
+	// certificates signed by a CA does not exceed the NotAfter of the CA
+	now := time.Now()
 	template := x509.Certificate{
-		SerialNumber:          big.NewInt(time.Now().UnixNano()),
-		DNSNames:              []string{dns},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().AddDate(0, 0, validityInt),
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		SerialNumber:          serialNumber,
+		Subject:               Subject(spec),
+		DNSNames:              DNSNames(spec),
+		IPAddresses:           ipAddresses,
+		URIs:                  uris,
+		EmailAddresses:        spec.EmailAddresses,
+		NotBefore:             now.Add(-opts.Backdate),
+		NotAfter:              opts.CA.ClampNotAfter(now.Add(duration)),
+		KeyUsage:              usage,
+		ExtKeyUsage:           extUsages,
 		BasicConstraintsValid: true,
 	}
+	if spec.IsCA {
+		setCAConstraints(&template, spec)
+	}
+
+	// Sign the certificate with the CA, or with its own key for self-signed certificates
+	parent, signer := &template, priv
+	if opts.CA != nil {
+		parent, signer = opts.CA.Certificate, opts.CA.PrivateKey
+	}
 
-	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, parent, priv.Public(), signer)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Encode the certificate and key to PEM format
+	// Encode the certificate and key to PEM format, certificates signed by a CA
+	// are followed by the chain of the CA
 	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
-	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+	if opts.CA != nil {
+		certPEM = append(certPEM, opts.CA.CertificatePEM...)
+	}
+	keyPEM, err := EncodePrivateKey(priv, KeyEncoding(spec.PrivateKey))
+	if err != nil {
+		return nil, nil, err
+	}
 
 	return certPEM, keyPEM, nil
 }
 
+// turn the certificate template into a CA certificate constrained by the spec
+func setCAConstraints(template *x509.Certificate, spec *certsv1.CertificateSpec) {
+	template.IsCA = true
+
+	template.MaxPathLen = maxPathLen(spec)
+	template.MaxPathLenZero = template.MaxPathLen == 0
+
+	template.PermittedDNSDomains = spec.PermittedDNSDomains
+	template.ExcludedDNSDomains = spec.ExcludedDNSDomains
+	template.PermittedDNSDomainsCritical = len(spec.PermittedDNSDomains) != 0 || len(spec.ExcludedDNSDomains) != 0
+}
+
+// get the maximum path length of a CA certificate, 0 when not set
+func maxPathLen(spec *certsv1.CertificateSpec) int {
+	if spec.MaxPathLen == nil {
+		return 0
+	}
+	return int(*spec.MaxPathLen)
+}
+
+// CheckCAPathLen returns an error when the path length constraint of the CA does not allow it to sign
+// the CA certificate requested by the spec. Leaf certificates and CAs without a constraint always pass
+func CheckCAPathLen(ca *CA, spec *certsv1.CertificateSpec) error {
+	if ca == nil || !spec.IsCA || ca.Certificate.MaxPathLen < 0 {
+		return nil
+	}
+	if maxPathLen(spec) >= ca.Certificate.MaxPathLen {
+		return fmt.Errorf("CA with maxPathLen %d cannot sign a CA certificate with maxPathLen %d", ca.Certificate.MaxPathLen, maxPathLen(spec))
+	}
+	return nil
+}
+
+// CheckCertCAConstraints reports whether the basic and name constraints of the certificate
+// match the ones requested by the spec
+func CheckCertCAConstraints(cert *x509.Certificate, spec *certsv1.CertificateSpec) bool {
+	if !spec.IsCA {
+		return !cert.IsCA
+	}
+	return cert.IsCA &&
+		cert.KeyUsage&x509.KeyUsageCertSign != 0 &&
+		cert.MaxPathLen == maxPathLen(spec) &&
+		sameStrings(cert.PermittedDNSDomains, spec.PermittedDNSDomains) &&
+		sameStrings(cert.ExcludedDNSDomains, spec.ExcludedDNSDomains)
+}
+
+// DNSNames returns the DNS names requested by the spec, the legacy dnsName
+// field comes first and duplicates are dropped
+func DNSNames(spec *certsv1.CertificateSpec) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range append([]string{spec.DnsName}, spec.DNSNames...) {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// IPAddresses parses the IP addresses requested by the spec
+func IPAddresses(spec *certsv1.CertificateSpec) ([]net.IP, error) {
+	var ips []net.IP
+	for _, addr := range spec.IPAddresses {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", addr)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// URIs parses the URIs requested by the spec
+func URIs(spec *certsv1.CertificateSpec) ([]*url.URL, error) {
+	var uris []*url.URL
+	for _, rawURI := range spec.URIs {
+		uri, err := url.Parse(rawURI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI %q: %v", rawURI, err)
+		}
+		uris = append(uris, uri)
+	}
+	return uris, nil
+}
+
+// Subject builds the distinguished name requested by the spec
+func Subject(spec *certsv1.CertificateSpec) pkix.Name {
+	return subjectName(spec.Subject)
+}
+
+// convert the subject of the API to a distinguished name
+func subjectName(subject *certsv1.X509Subject) pkix.Name {
+	if subject == nil {
+		return pkix.Name{}
+	}
+	return pkix.Name{
+		CommonName:         subject.CommonName,
+		Organization:       subject.Organizations,
+		OrganizationalUnit: subject.OrganizationalUnits,
+		Country:            subject.Countries,
+		Locality:           subject.Localities,
+		Province:           subject.Provinces,
+		StreetAddress:      subject.StreetAddresses,
+		PostalCode:         subject.PostalCodes,
+		SerialNumber:       subject.SerialNumber,
+	}
+}
+
+// CheckCertSubject reports whether the subject of the certificate matches the one requested by the spec
+func CheckCertSubject(cert *x509.Certificate, spec *certsv1.CertificateSpec) bool {
+	expected := Subject(spec)
+	actual := cert.Subject
+
+	return actual.CommonName == expected.CommonName &&
+		actual.SerialNumber == expected.SerialNumber &&
+		sameStrings(actual.Organization, expected.Organization) &&
+		sameStrings(actual.OrganizationalUnit, expected.OrganizationalUnit) &&
+		sameStrings(actual.Country, expected.Country) &&
+		sameStrings(actual.Locality, expected.Locality) &&
+		sameStrings(actual.Province, expected.Province) &&
+		sameStrings(actual.StreetAddress, expected.StreetAddress) &&
+		sameStrings(actual.PostalCode, expected.PostalCode)
+}
+
+// CheckCertSANs reports whether the subject alternative names of the certificate
+// are exactly the ones requested by the spec, regardless of their order
+func CheckCertSANs(cert *x509.Certificate, spec *certsv1.CertificateSpec) (bool, error) {
+	ipAddresses, err := IPAddresses(spec)
+	if err != nil {
+		return false, err
+	}
+	uris, err := URIs(spec)
+	if err != nil {
+		return false, err
+	}
+
+	return sameStrings(cert.DNSNames, DNSNames(spec)) &&
+		sameStrings(ipStrings(cert.IPAddresses), ipStrings(ipAddresses)) &&
+		sameStrings(uriStrings(cert.URIs), uriStrings(uris)) &&
+		sameStrings(cert.EmailAddresses, spec.EmailAddresses), nil
+}
+
+func ipStrings(ips []net.IP) []string {
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+func uriStrings(uris []*url.URL) []string {
+	out := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		out = append(out, uri.String())
+	}
+	return out
+}
+
+// compare two string slices as sets
+func sameStrings(a, b []string) bool {
+	setA := make(map[string]bool, len(a))
+	for _, s := range a {
+		setA[s] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, s := range b {
+		setB[s] = true
+	}
+	if len(setA) != len(setB) {
+		return false
+	}
+	for s := range setA {
+		if !setB[s] {
+			return false
+		}
+	}
+	return true
+}
+
 // get the numeric part of the validity of the certificate
 func extractDaysOfValidity(val string) (int, error) {
 	val = strings.TrimSuffix(val, "d")
@@ -61,89 +327,115 @@ func extractDaysOfValidity(val string) (int, error) {
 	return days, nil
 }
 
-func CheckCertValidity(notBefore, notAfter time.Time, validity string) (bool, error) {
-	// Calculate the expected expiration date based on the CR's validity field
-	daysStr := strings.TrimSuffix(validity, "d")
-	validityDays, err := strconv.Atoi(daysStr)
+// validityPattern is the format of validities accepted by the webhook, a number of days such as 365d
+var validityPattern = regexp.MustCompile(`^\d+d$`)
+
+// IsValidity reports whether the validity is a number of days followed by 'd'
+func IsValidity(validity string) bool {
+	return validityPattern.MatchString(validity)
+}
+
+// ValidityDuration converts the validity of the certificate to a duration
+func ValidityDuration(validity string) (time.Duration, error) {
+	days, err := extractDaysOfValidity(validity)
 	if err != nil {
-		return false, fmt.Errorf("invalid validity format in CR: %v", err)
+		return 0, err
 	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
 
-	expectedExpiration := notBefore.Add(time.Duration(validityDays) * 24 * time.Hour)
+// CertificateDuration returns the lifetime requested by the spec, the duration
+// field takes precedence over the legacy validity in days
+func CertificateDuration(spec *certsv1.CertificateSpec) (time.Duration, error) {
+	return LifetimeDuration(spec.Validity, spec.Duration)
+}
 
-	// Check if the certificate's expiration date matches the expected expiration date
-	if !notAfter.Equal(expectedExpiration) {
-		return false, nil
+// LifetimeDuration returns the lifetime given as a Go duration, or as a validity
+// in days when the duration is nil
+func LifetimeDuration(validity string, duration *metav1.Duration) (time.Duration, error) {
+	if duration != nil {
+		return duration.Duration, nil
 	}
-	return true, nil
-
+	return ValidityDuration(validity)
 }
 
-func CheckCertKey(cert *x509.Certificate, privKey *rsa.PrivateKey) (bool, error) {
-	pubKey, ok := cert.PublicKey.(*rsa.PublicKey)
-	if !ok {
-		return false, fmt.Errorf("certificate does not contain an RSA public key")
+// RenewalTime computes when a certificate valid between notBefore and notAfter must be renewed.
+// It defaults to two thirds of the lifetime, RenewBefore and RenewBeforePercentage are ignored
+// when they exceed the lifetime of the certificate
+func RenewalTime(notBefore, notAfter time.Time, spec *certsv1.CertificateSpec) time.Time {
+	lifetime := notAfter.Sub(notBefore)
+	renewBefore := lifetime / 3
+
+	switch {
+	case spec.RenewBefore != nil && spec.RenewBefore.Duration > 0 && spec.RenewBefore.Duration < lifetime:
+		renewBefore = spec.RenewBefore.Duration
+	case spec.RenewBeforePercentage != nil && *spec.RenewBeforePercentage > 0 && *spec.RenewBeforePercentage < 100:
+		renewBefore = lifetime * time.Duration(*spec.RenewBeforePercentage) / 100
 	}
 
-	// Compare the modulus of both keys
-	return pubKey.N.Cmp(privKey.N) == 0, nil
+	return notAfter.Add(-renewBefore)
 }
 
-func ExtractCertData(secret corev1.Secret) (x509.Certificate, error) {
-	// Extract the tls.crt field from the secret
-	certData, exists := secret.Data["tls.crt"]
-	if !exists {
-		return x509.Certificate{}, errors.New("secret does not contain tls.crt field")
+// CheckCertValidity reports whether the certificate is currently valid and whether its
+// lifetime matches the requested duration for certificates backdated by backdate
+func CheckCertValidity(notBefore, notAfter time.Time, duration, backdate time.Duration) bool {
+	// An expired or not yet valid certificate must be reissued
+	now := time.Now()
+	if now.Before(notBefore) || !now.Before(notAfter) {
+		return false
 	}
+	return CheckCertLifetime(notBefore, notAfter, duration, backdate)
+}
+
+// CheckCertLifetime reports whether the lifetime between notBefore and notAfter matches the
+// requested duration. The lifetime is compared as an elapsed duration, independently of time
+// zones and calendar days, and may exceed the duration by up to backdate. Differences below
+// the one second precision of certificate times are ignored
+func CheckCertLifetime(notBefore, notAfter time.Time, duration, backdate time.Duration) bool {
+	lifetime := notAfter.Sub(notBefore)
+	return lifetime > duration-validityTolerance && lifetime < duration+backdate+validityTolerance
+}
 
-	// Decode and parse the certificate
-	block, _ := pem.Decode(certData)
+// ParseCertificate decodes and parses the first certificate of a PEM bundle
+func ParseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
 	if block == nil {
-		return x509.Certificate{}, errors.New("failed to decode PEM block containing the certificate")
+		return nil, errors.New("failed to decode PEM block containing the certificate")
 	}
 
 	parsedCert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return x509.Certificate{}, fmt.Errorf("failed to parse certificate: %v", err)
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
 	}
 
-	return *parsedCert, nil
+	return parsedCert, nil
 }
 
-func ExtractKeyData(secret corev1.Secret) (rsa.PrivateKey, error) {
-	keyData, exists := secret.Data["tls.key"]
-	if !exists {
-		return rsa.PrivateKey{}, errors.New("secret does not contain tls.key field")
+// ParseCertificates decodes and parses every certificate of a PEM bundle
+func ParseCertificates(bundle []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(bundle); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %v", err)
+		}
+		certs = append(certs, cert)
 	}
-
-	block, _ := pem.Decode(keyData)
-	if block == nil || block.Type != "RSA PRIVATE KEY" {
-		return rsa.PrivateKey{}, fmt.Errorf("failed to parse private key PEM")
-	}
-
-	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-	if err != nil {
-		return rsa.PrivateKey{}, fmt.Errorf("failed to parse private key: %v", err)
+	if len(certs) == 0 {
+		return nil, errors.New("failed to decode PEM block containing the certificate")
 	}
-
-	return *privateKey, nil
+	return certs, nil
 }
 
-func GeneratePrivateKeyPEM() ([]byte, error) {
-	// Generate a new RSA private key
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate private key: %w", err)
+// Fingerprint returns the colon separated SHA-256 fingerprint of the certificate
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, 0, len(sum))
+	for _, b := range sum {
+		parts = append(parts, fmt.Sprintf("%02X", b))
 	}
-
-	// Marshal the private key into PKCS#1 ASN.1 DER format
-	privateKeyDER := x509.MarshalPKCS1PrivateKey(privateKey)
-
-	// Encode the private key to PEM format
-	privateKeyPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: privateKeyDER,
-	})
-
-	return privateKeyPEM, nil
+	return strings.Join(parts, ":")
 }
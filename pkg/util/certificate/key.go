@@ -0,0 +1,202 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+)
+
+const (
+	DefaultRSAKeySize   = 2048
+	DefaultECDSAKeySize = 256
+)
+
+// KeyAlgorithm returns the algorithm and size of the private key, falling back
+// to a 2048 bits RSA key when nothing is specified
+func KeyAlgorithm(privateKey *certsv1.CertificatePrivateKey) (certsv1.PrivateKeyAlgorithm, int) {
+	algorithm, size := certsv1.RSAKeyAlgorithm, 0
+	if privateKey != nil {
+		if privateKey.Algorithm != "" {
+			algorithm = privateKey.Algorithm
+		}
+		size = privateKey.Size
+	}
+
+	switch algorithm {
+	case certsv1.RSAKeyAlgorithm:
+		if size == 0 {
+			size = DefaultRSAKeySize
+		}
+	case certsv1.ECDSAKeyAlgorithm:
+		if size == 0 {
+			size = DefaultECDSAKeySize
+		}
+	case certsv1.Ed25519KeyAlgorithm:
+		size = 0
+	}
+	return algorithm, size
+}
+
+// KeyEncoding returns the encoding of the private key, PKCS1 is the default
+// except for Ed25519 keys which can only be encoded with PKCS8
+func KeyEncoding(privateKey *certsv1.CertificatePrivateKey) certsv1.PrivateKeyEncoding {
+	if privateKey != nil && privateKey.Encoding != "" {
+		return privateKey.Encoding
+	}
+	if algorithm, _ := KeyAlgorithm(privateKey); algorithm == certsv1.Ed25519KeyAlgorithm {
+		return certsv1.PKCS8KeyEncoding
+	}
+	return certsv1.PKCS1KeyEncoding
+}
+
+// RotationPolicy returns the rotation policy of the private key, Always by default
+func RotationPolicy(privateKey *certsv1.CertificatePrivateKey) certsv1.PrivateKeyRotationPolicy {
+	if privateKey != nil && privateKey.RotationPolicy != "" {
+		return privateKey.RotationPolicy
+	}
+	return certsv1.AlwaysRotationPolicy
+}
+
+// GeneratePrivateKey generates a private key matching the requested algorithm and size
+func GeneratePrivateKey(privateKey *certsv1.CertificatePrivateKey) (crypto.Signer, error) {
+	algorithm, size := KeyAlgorithm(privateKey)
+
+	switch algorithm {
+	case certsv1.RSAKeyAlgorithm:
+		switch size {
+		case 2048, 3072, 4096:
+			return rsa.GenerateKey(rand.Reader, size)
+		}
+		return nil, fmt.Errorf("unsupported RSA key size %d", size)
+	case certsv1.ECDSAKeyAlgorithm:
+		curve, err := ecdsaCurve(size)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case certsv1.Ed25519KeyAlgorithm:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return priv, nil
+	}
+	return nil, fmt.Errorf("unsupported private key algorithm %q", algorithm)
+}
+
+func ecdsaCurve(size int) (elliptic.Curve, error) {
+	switch size {
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	}
+	return nil, fmt.Errorf("unsupported ECDSA key size %d", size)
+}
+
+// EncodePrivateKey encodes the private key to PEM. With PKCS1, RSA keys use PKCS#1
+// and ECDSA keys use SEC 1, Ed25519 keys have no such format and require PKCS8
+func EncodePrivateKey(key crypto.Signer, encoding certsv1.PrivateKeyEncoding) ([]byte, error) {
+	var block *pem.Block
+
+	switch encoding {
+	case certsv1.PKCS1KeyEncoding:
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+		case *ecdsa.PrivateKey:
+			der, err := x509.MarshalECPrivateKey(k)
+			if err != nil {
+				return nil, err
+			}
+			block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		default:
+			return nil, fmt.Errorf("private key type %T cannot be encoded with PKCS1", key)
+		}
+	case certsv1.PKCS8KeyEncoding:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		return nil, fmt.Errorf("unsupported private key encoding %q", encoding)
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+// CheckKeyEncoding reports whether the PEM encoded private key uses the requested encoding
+func CheckKeyEncoding(keyPEM []byte, privateKey *certsv1.CertificatePrivateKey) bool {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return false
+	}
+
+	switch KeyEncoding(privateKey) {
+	case certsv1.PKCS1KeyEncoding:
+		return block.Type == "RSA PRIVATE KEY" || block.Type == "EC PRIVATE KEY"
+	case certsv1.PKCS8KeyEncoding:
+		return block.Type == "PRIVATE KEY"
+	}
+	return false
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS#1, SEC 1 or PKCS#8 private key
+func ParsePrivateKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block containing the private key")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	return nil, fmt.Errorf("unsupported private key PEM block type %q", block.Type)
+}
+
+// CheckKeyAlgorithm reports whether the private key uses the requested algorithm and size
+func CheckKeyAlgorithm(key crypto.Signer, privateKey *certsv1.CertificatePrivateKey) bool {
+	algorithm, size := KeyAlgorithm(privateKey)
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return algorithm == certsv1.RSAKeyAlgorithm && k.N.BitLen() == size
+	case *ecdsa.PrivateKey:
+		return algorithm == certsv1.ECDSAKeyAlgorithm && k.Curve.Params().BitSize == size
+	case ed25519.PrivateKey:
+		return algorithm == certsv1.Ed25519KeyAlgorithm
+	}
+	return false
+}
+
+// CheckCertKey reports whether the private key matches the public key of the certificate
+func CheckCertKey(cert *x509.Certificate, key crypto.Signer) (bool, error) {
+	pubKey, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false, fmt.Errorf("unsupported public key type %T", key.Public())
+	}
+	return pubKey.Equal(cert.PublicKey), nil
+}
@@ -0,0 +1,102 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"slices"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+)
+
+// key usages of the API and their x509 counterpart
+var keyUsages = map[certsv1.KeyUsage]x509.KeyUsage{
+	certsv1.UsageSigning:           x509.KeyUsageDigitalSignature,
+	certsv1.UsageDigitalSignature:  x509.KeyUsageDigitalSignature,
+	certsv1.UsageContentCommitment: x509.KeyUsageContentCommitment,
+	certsv1.UsageKeyEncipherment:   x509.KeyUsageKeyEncipherment,
+	certsv1.UsageKeyAgreement:      x509.KeyUsageKeyAgreement,
+	certsv1.UsageDataEncipherment:  x509.KeyUsageDataEncipherment,
+	certsv1.UsageCertSign:          x509.KeyUsageCertSign,
+	certsv1.UsageCRLSign:           x509.KeyUsageCRLSign,
+	certsv1.UsageEncipherOnly:      x509.KeyUsageEncipherOnly,
+	certsv1.UsageDecipherOnly:      x509.KeyUsageDecipherOnly,
+}
+
+// extended key usages of the API and their x509 counterpart
+var extKeyUsages = map[certsv1.KeyUsage]x509.ExtKeyUsage{
+	certsv1.UsageAny:             x509.ExtKeyUsageAny,
+	certsv1.UsageServerAuth:      x509.ExtKeyUsageServerAuth,
+	certsv1.UsageClientAuth:      x509.ExtKeyUsageClientAuth,
+	certsv1.UsageCodeSigning:     x509.ExtKeyUsageCodeSigning,
+	certsv1.UsageEmailProtection: x509.ExtKeyUsageEmailProtection,
+	certsv1.UsageSMIME:           x509.ExtKeyUsageEmailProtection,
+	certsv1.UsageIPsecEndSystem:  x509.ExtKeyUsageIPSECEndSystem,
+	certsv1.UsageIPsecTunnel:     x509.ExtKeyUsageIPSECTunnel,
+	certsv1.UsageIPsecUser:       x509.ExtKeyUsageIPSECUser,
+	certsv1.UsageTimestamping:    x509.ExtKeyUsageTimeStamping,
+	certsv1.UsageOCSPSigning:     x509.ExtKeyUsageOCSPSigning,
+	certsv1.UsageMicrosoftSGC:    x509.ExtKeyUsageMicrosoftServerGatedCrypto,
+	certsv1.UsageNetscapeSGC:     x509.ExtKeyUsageNetscapeServerGatedCrypto,
+}
+
+// IsKeyUsage reports whether the usage is a supported key usage or extended key usage
+func IsKeyUsage(usage certsv1.KeyUsage) bool {
+	_, isKeyUsage := keyUsages[usage]
+	_, isExtKeyUsage := extKeyUsages[usage]
+	return isKeyUsage || isExtKeyUsage
+}
+
+// KeyUsages returns the key usages and extended key usages of a certificate for the key.
+// Without usages in the spec, certificates are used for digital signatures, key encipherment
+// with RSA keys and server authentication. CA certificates can always sign certificates and
+// CRLs, and key encipherment is dropped for keys other than RSA
+func KeyUsages(spec *certsv1.CertificateSpec, key crypto.Signer) (x509.KeyUsage, []x509.ExtKeyUsage, error) {
+	var usage x509.KeyUsage
+	var extUsages []x509.ExtKeyUsage
+
+	if len(spec.Usages) == 0 {
+		usage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		if !spec.IsCA {
+			extUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		}
+	}
+	for _, u := range spec.Usages {
+		if ku, ok := keyUsages[u]; ok {
+			usage |= ku
+		} else if eku, ok := extKeyUsages[u]; ok {
+			if !slices.Contains(extUsages, eku) {
+				extUsages = append(extUsages, eku)
+			}
+		} else {
+			return 0, nil, fmt.Errorf("unsupported usage %q", u)
+		}
+	}
+
+	if spec.IsCA {
+		usage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	if _, ok := key.(*rsa.PrivateKey); !ok {
+		usage &^= x509.KeyUsageKeyEncipherment
+	}
+	return usage, extUsages, nil
+}
+
+// CheckCertUsages reports whether the key usages and extended key usages of the certificate
+// match the ones requested by the spec
+func CheckCertUsages(cert *x509.Certificate, spec *certsv1.CertificateSpec, key crypto.Signer) (bool, error) {
+	usage, extUsages, err := KeyUsages(spec, key)
+	if err != nil {
+		return false, err
+	}
+	if cert.KeyUsage != usage || len(cert.ExtKeyUsage) != len(extUsages) {
+		return false, nil
+	}
+	for _, eku := range extUsages {
+		if !slices.Contains(cert.ExtKeyUsage, eku) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,189 @@
+package issuer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	"github.com/AKI-25/certaur/pkg/util/certificate"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultClusterResourceNamespace is the namespace holding the secrets of ClusterIssuers
+	DefaultClusterResourceNamespace = "certaur-system"
+	// CASecretNameIndex indexes issuers by the name of the secret holding their CA keypair
+	CASecretNameIndex = ".spec.caSecretName"
+	// IssuerRefIndex indexes certificates by the kind and name of the issuer signing them
+	IssuerRefIndex = ".spec.issuerRef"
+)
+
+// ErrCAExpired is returned for issuers whose CA certificate has expired
+var ErrCAExpired = errors.New("CA certificate has expired")
+
+// GetIssuer fetches the Issuer or ClusterIssuer referenced by the certificate
+func GetIssuer(ctx context.Context, Client client.Client, cert *certsv1.Certificate) (certsv1.GenericIssuer, error) {
+	ref := cert.Spec.IssuerRef
+
+	var issuer certsv1.GenericIssuer
+	key := types.NamespacedName{Name: ref.Name}
+	switch ref.Kind {
+	case "", certsv1.IssuerKind:
+		issuer = &certsv1.Issuer{}
+		key.Namespace = cert.Namespace
+	case certsv1.ClusterIssuerKind:
+		issuer = &certsv1.ClusterIssuer{}
+	default:
+		return nil, fmt.Errorf("unsupported issuer kind %q", ref.Kind)
+	}
+
+	if err := Client.Get(ctx, key, issuer); err != nil {
+		return nil, err
+	}
+	return issuer, nil
+}
+
+// SecretNamespace returns the namespace of the secrets referenced by the issuer
+func SecretNamespace(issuer certsv1.GenericIssuer, clusterResourceNamespace string) string {
+	if issuer.GetNamespace() != "" {
+		return issuer.GetNamespace()
+	}
+	if clusterResourceNamespace == "" {
+		return DefaultClusterResourceNamespace
+	}
+	return clusterResourceNamespace
+}
+
+// CASecretName returns the name of the secret holding the CA keypair of the issuer,
+// an empty name is returned for self-signed issuers
+func CASecretName(issuer certsv1.GenericIssuer) string {
+	spec := issuer.GetSpec()
+	switch {
+	case spec.CA != nil:
+		return spec.CA.SecretName
+	case spec.RootCA != nil:
+		return spec.RootCA.SecretName
+	}
+	return ""
+}
+
+// IndexCASecretName returns the name of the CA secret of the issuer, used to look up the issuers
+// affected by a change of their secret
+func IndexCASecretName(obj client.Object) []string {
+	issuer, ok := obj.(certsv1.GenericIssuer)
+	if !ok {
+		return nil
+	}
+	if name := CASecretName(issuer); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+// IssuerRefKey returns the value of the IssuerRefIndex of the certificates signed by the issuer
+func IssuerRefKey(kind, name string) string {
+	if kind == "" {
+		kind = certsv1.IssuerKind
+	}
+	return kind + "/" + name
+}
+
+// IndexIssuerRef returns the kind and name of the issuer of the certificate, used to look up the
+// certificates affected by a change of the CA secret of their issuer
+func IndexIssuerRef(obj client.Object) []string {
+	cert, ok := obj.(*certsv1.Certificate)
+	if !ok || cert.Spec.IssuerRef == nil {
+		return nil
+	}
+	return []string{IssuerRefKey(cert.Spec.IssuerRef.Kind, cert.Spec.IssuerRef.Name)}
+}
+
+// BootstrapRootCA generates the root CA keypair of the issuer and stores it in its secret
+// unless the secret already exists. It reports whether a new CA has been generated
+func BootstrapRootCA(ctx context.Context, Client client.Client, issuer certsv1.GenericIssuer, clusterResourceNamespace string, opts certificate.IssueOptions) (bool, error) {
+	spec := issuer.GetSpec().RootCA
+	if spec == nil {
+		return false, nil
+	}
+
+	key := types.NamespacedName{Name: spec.SecretName, Namespace: SecretNamespace(issuer, clusterResourceNamespace)}
+	existing := &corev1.Secret{}
+	err := Client.Get(ctx, key, existing)
+	if err == nil {
+		return false, nil
+	} else if !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get CA secret %s: %w", key, err)
+	}
+
+	certPEM, keyPEM, err := certificate.GenerateRootCA(issuer.GetName(), spec, opts)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate root CA: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    map[string]string{secretutil.ManagedLabel: "true"},
+		},
+		Data: map[string][]byte{
+			"tls.crt": certPEM,
+			"tls.key": keyPEM,
+			"ca.crt":  certPEM,
+		},
+		Type: corev1.SecretTypeTLS,
+	}
+	if err := Client.Create(ctx, secret); err != nil {
+		return false, fmt.Errorf("failed to create CA secret %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// LoadCA reads and verifies the CA keypair of the issuer, nil is returned for self-signed issuers.
+// ErrCAExpired is returned once the CA certificate has expired
+func LoadCA(ctx context.Context, Client client.Client, issuer certsv1.GenericIssuer, clusterResourceNamespace string) (*certificate.CA, error) {
+	secretName := CASecretName(issuer)
+	if secretName == "" {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: secretName, Namespace: SecretNamespace(issuer, clusterResourceNamespace)}
+	if err := Client.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("failed to get CA secret %s: %w", key, err)
+	}
+
+	ca, err := certificate.ExtractCAData(*secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA secret %s: %w", key, err)
+	}
+	if notAfter := ca.Certificate.NotAfter; !time.Now().Before(notAfter) {
+		return nil, fmt.Errorf("CA secret %s: %w on %s", key, ErrCAExpired, notAfter.UTC().Format(time.RFC3339))
+	}
+	return ca, nil
+}
+
+// ResolveCA returns the CA signing the certificate, nil is returned for self-signed certificates
+func ResolveCA(ctx context.Context, Client client.Client, cert *certsv1.Certificate, clusterResourceNamespace string) (*certificate.CA, error) {
+	if cert.Spec.IssuerRef == nil {
+		return nil, nil
+	}
+
+	issuer, err := GetIssuer(ctx, Client, cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issuer %s: %w", cert.Spec.IssuerRef.Name, err)
+	}
+	if !meta.IsStatusConditionTrue(issuer.GetStatus().Conditions, certsv1.IssuerConditionReady) {
+		return nil, fmt.Errorf("issuer %s is not ready", issuer.GetName())
+	}
+
+	return LoadCA(ctx, Client, issuer, clusterResourceNamespace)
+}
@@ -0,0 +1,213 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	jks "github.com/pavlo-v-chernykh/keystore-go/v4"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// keys of the keystores in the secret of a certificate
+const (
+	PKCS12KeystoreKey   = "keystore.p12"
+	PKCS12TruststoreKey = "truststore.p12"
+	JKSKeystoreKey      = "keystore.jks"
+	JKSTruststoreKey    = "truststore.jks"
+)
+
+// aliases of the entries of JKS keystores
+const (
+	certificateAlias = "certificate"
+	caAlias          = "ca"
+)
+
+// type of the certificates stored in JKS keystores
+const jksCertificateType = "X509"
+
+// Passwords holds the passwords protecting the keystores of a certificate
+type Passwords struct {
+	PKCS12 string
+	JKS    string
+}
+
+// PKCS12 encoders by profile name
+var pkcs12Encoders = map[string]*pkcs12.Encoder{
+	"LegacyRC2":  pkcs12.LegacyRC2,
+	"LegacyDES":  pkcs12.LegacyDES,
+	"Modern2023": pkcs12.Modern2023,
+}
+
+// DefaultPKCS12Profile is the profile of PKCS#12 keystores that do not specify one
+const DefaultPKCS12Profile = "Modern2023"
+
+// EncodePKCS12 encodes the key and its certificate chain in a PKCS#12 keystore with the encoder of the profile
+func EncodePKCS12(key crypto.Signer, chain []*x509.Certificate, password, profile string) ([]byte, error) {
+	encoder, err := pkcs12Encoder(profile)
+	if err != nil {
+		return nil, err
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("pkcs12: certificate chain is empty")
+	}
+	return encoder.Encode(key, chain[0], chain[1:], password)
+}
+
+// EncodePKCS12TrustStore encodes the CA certificates in a PKCS#12 truststore with the encoder of the profile
+func EncodePKCS12TrustStore(cas []*x509.Certificate, password, profile string) ([]byte, error) {
+	encoder, err := pkcs12Encoder(profile)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.EncodeTrustStore(cas, password)
+}
+
+// CheckPKCS12 reports whether the PKCS#12 keystore holds the key and its certificate chain
+func CheckPKCS12(data []byte, password string, key crypto.Signer, chain []*x509.Certificate) bool {
+	storedKey, cert, cas, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return false
+	}
+	signer, ok := storedKey.(crypto.Signer)
+	return ok && sameKey(signer, key) && sameCertificates(append([]*x509.Certificate{cert}, cas...), chain)
+}
+
+// CheckPKCS12TrustStore reports whether the PKCS#12 truststore holds the CA certificates
+func CheckPKCS12TrustStore(data []byte, password string, cas []*x509.Certificate) bool {
+	certs, err := pkcs12.DecodeTrustStore(data, password)
+	return err == nil && sameCertificates(certs, cas)
+}
+
+// EncodeJKSKeystore encodes the key and its certificate chain in a JKS keystore, the password
+// protects both the keystore and the private key
+func EncodeJKSKeystore(key crypto.Signer, chain []*x509.Certificate, password string) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	certs := make([]jks.Certificate, 0, len(chain))
+	for _, cert := range chain {
+		certs = append(certs, jksCertificate(cert))
+	}
+
+	ks := jks.New()
+	entry := jks.PrivateKeyEntry{CreationTime: time.Now(), PrivateKey: der, CertificateChain: certs}
+	if err := ks.SetPrivateKeyEntry(certificateAlias, entry, []byte(password)); err != nil {
+		return nil, err
+	}
+	return storeJKS(ks, password)
+}
+
+// EncodeJKSTrustStore encodes the CA certificates in a JKS truststore
+func EncodeJKSTrustStore(cas []*x509.Certificate, password string) ([]byte, error) {
+	ks := jks.New()
+	for i, ca := range cas {
+		entry := jks.TrustedCertificateEntry{CreationTime: time.Now(), Certificate: jksCertificate(ca)}
+		if err := ks.SetTrustedCertificateEntry(trustStoreAlias(i), entry); err != nil {
+			return nil, err
+		}
+	}
+	return storeJKS(ks, password)
+}
+
+// CheckJKSKeystore reports whether the JKS keystore holds the key and its certificate chain
+func CheckJKSKeystore(data []byte, password string, key crypto.Signer, chain []*x509.Certificate) bool {
+	ks, err := loadJKS(data, password)
+	if err != nil || len(ks.Aliases()) != 1 {
+		return false
+	}
+	entry, err := ks.GetPrivateKeyEntry(certificateAlias, []byte(password))
+	if err != nil {
+		return false
+	}
+	storedKey, err := x509.ParsePKCS8PrivateKey(entry.PrivateKey)
+	if err != nil {
+		return false
+	}
+	signer, ok := storedKey.(crypto.Signer)
+	if !ok || !sameKey(signer, key) || len(entry.CertificateChain) != len(chain) {
+		return false
+	}
+	for i, cert := range entry.CertificateChain {
+		if !sameJKSCertificate(cert, chain[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckJKSTrustStore reports whether the JKS truststore holds the CA certificates
+func CheckJKSTrustStore(data []byte, password string, cas []*x509.Certificate) bool {
+	ks, err := loadJKS(data, password)
+	if err != nil || len(ks.Aliases()) != len(cas) {
+		return false
+	}
+	for i, ca := range cas {
+		entry, err := ks.GetTrustedCertificateEntry(trustStoreAlias(i))
+		if err != nil || !sameJKSCertificate(entry.Certificate, ca) {
+			return false
+		}
+	}
+	return true
+}
+
+// trustStoreAlias returns the alias of the i-th CA certificate of a JKS truststore
+func trustStoreAlias(i int) string {
+	if i == 0 {
+		return caAlias
+	}
+	return fmt.Sprintf("%s-%d", caAlias, i)
+}
+
+func jksCertificate(cert *x509.Certificate) jks.Certificate {
+	return jks.Certificate{Type: jksCertificateType, Content: cert.Raw}
+}
+
+func sameJKSCertificate(a jks.Certificate, b *x509.Certificate) bool {
+	return a.Type == jksCertificateType && bytes.Equal(a.Content, b.Raw)
+}
+
+func storeJKS(ks jks.KeyStore, password string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, []byte(password)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func loadJKS(data []byte, password string) (jks.KeyStore, error) {
+	ks := jks.New()
+	return ks, ks.Load(bytes.NewReader(data), []byte(password))
+}
+
+func pkcs12Encoder(profile string) (*pkcs12.Encoder, error) {
+	if profile == "" {
+		profile = DefaultPKCS12Profile
+	}
+	encoder, ok := pkcs12Encoders[profile]
+	if !ok {
+		return nil, fmt.Errorf("unsupported PKCS#12 profile %q", profile)
+	}
+	return encoder, nil
+}
+
+func sameKey(a, b crypto.Signer) bool {
+	pubKey, ok := a.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pubKey.Equal(b.Public())
+}
+
+func sameCertificates(a, b []*x509.Certificate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
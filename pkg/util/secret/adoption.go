@@ -0,0 +1,77 @@
+package secret
+
+import (
+	"context"
+	"slices"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OrphanedLabel marks the secrets retained after the deletion of their certificate
+	OrphanedLabel = "certs.k8c.io/orphaned"
+	// AdoptionAnnotation opts a secret created outside of certaur in to be adopted by
+	// the certificate referencing it
+	AdoptionAnnotation = "certs.k8c.io/allow-adoption"
+)
+
+// SecretDeletionPolicy returns the secret deletion policy of the certificate, Delete by default
+func SecretDeletionPolicy(cert *certsv1.Certificate) certsv1.SecretDeletionPolicy {
+	if cert.Spec.SecretDeletionPolicy == "" {
+		return certsv1.DeleteSecretDeletionPolicy
+	}
+	return cert.Spec.SecretDeletionPolicy
+}
+
+// IsOrphaned reports whether the secret has been retained after the deletion of its certificate
+// and has not been adopted since
+func IsOrphaned(secret *corev1.Secret) bool {
+	return secret.Labels[OrphanedLabel] == "true" && metav1.GetControllerOf(secret) == nil
+}
+
+// IsAdoptable reports whether the secret can be adopted by the certificate referencing it: it has
+// no controller and has either been retained after the deletion of its certificate or opted in
+// with the adoption annotation
+func IsAdoptable(secret *corev1.Secret) bool {
+	if metav1.GetControllerOf(secret) != nil {
+		return false
+	}
+	return secret.Labels[OrphanedLabel] == "true" || secret.Annotations[AdoptionAnnotation] == "true"
+}
+
+// OrphanSecret removes the owner reference of the certificate and the managed label from its secret and
+// labels the secret as orphaned, so that the secret survives the deletion of the certificate
+func OrphanSecret(ctx context.Context, Client client.Client, cert *certsv1.Certificate) error {
+	secret := &corev1.Secret{}
+	err := Client.Get(ctx, types.NamespacedName{Name: cert.Spec.SecretRef.Name, Namespace: cert.Namespace}, secret)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !IsOwnerReference(cert, secret) {
+		return nil
+	}
+
+	secret.OwnerReferences = slices.DeleteFunc(secret.OwnerReferences, func(owner metav1.OwnerReference) bool {
+		return owner.APIVersion == certsv1.GroupVersion.String() && owner.Kind == "Certificate" && owner.Name == cert.Name
+	})
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Labels[OrphanedLabel] = "true"
+	delete(secret.Labels, ManagedLabel)
+	return Client.Update(ctx, secret)
+}
+
+// AdoptSecret makes the certificate the controller of an adoptable secret, the material of the
+// secret is kept as long as it passes the integrity check of the certificate
+func AdoptSecret(ctx context.Context, Client client.Client, cert *certsv1.Certificate, secret *corev1.Secret) error {
+	delete(secret.Labels, OrphanedLabel)
+	delete(secret.Annotations, AdoptionAnnotation)
+	SetManagedLabel(secret)
+	secret.OwnerReferences = append(secret.OwnerReferences, *metav1.NewControllerRef(cert, certsv1.GroupVersion.WithKind("Certificate")))
+	return Client.Update(ctx, secret)
+}
@@ -0,0 +1,140 @@
+package secret
+
+import (
+	"context"
+	"slices"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ManagedLabel marks the secrets managed by certaur, the only secrets held in the cache of the manager
+	ManagedLabel = "certs.k8c.io/managed"
+	// OwnerUIDIndex indexes secrets by the UID of the certificates owning them
+	OwnerUIDIndex = ".metadata.ownerReferences.certificateUID"
+	// PasswordSecretIndex indexes certificates by the secrets holding the passwords of their keystores
+	PasswordSecretIndex = ".spec.keystores.passwordSecretRef.name"
+)
+
+// ManagedSelector selects the secrets managed by certaur
+func ManagedSelector() labels.Selector {
+	return labels.SelectorFromSet(labels.Set{ManagedLabel: "true"})
+}
+
+// CacheOptions restricts the secret cache of the manager to the secrets managed by certaur and strips
+// their managed fields, so that the memory of the manager does not grow with unrelated secrets such as
+// Helm releases. Secrets missing from the cache are read through the FallbackClient
+func CacheOptions() cache.ByObject {
+	return cache.ByObject{
+		Label:     ManagedSelector(),
+		Transform: cache.TransformStripManagedFields(),
+	}
+}
+
+// NewMetadataCache returns a cache of the metadata of every secret of the namespaces, added to the manager.
+// The cache of the manager only holds the secrets managed by certaur, this cache lets the controllers
+// watch the secrets they read without owning them, such as password and CA secrets, without labelling
+// them and without holding their data in memory
+func NewMetadataCache(mgr ctrl.Manager, namespaces map[string]cache.Config) (cache.Cache, error) {
+	opts := MetadataCacheOptions(namespaces)
+	opts.HTTPClient = mgr.GetHTTPClient()
+	opts.Scheme = mgr.GetScheme()
+	opts.Mapper = mgr.GetRESTMapper()
+	metadataCache, err := cache.New(mgr.GetConfig(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return metadataCache, mgr.Add(metadataCache)
+}
+
+// MetadataCacheOptions returns the options of the metadata cache of the namespaces, without the clients of the manager
+func MetadataCacheOptions(namespaces map[string]cache.Config) cache.Options {
+	return cache.Options{
+		DefaultNamespaces: namespaces,
+		DefaultTransform:  cache.TransformStripManagedFields(),
+	}
+}
+
+// SecretMetadata returns the object watched in the metadata cache for secrets
+func SecretMetadata() *metav1.PartialObjectMetadata {
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	return secret
+}
+
+// IndexOwnerUID returns the UIDs of the certificates owning the secret
+func IndexOwnerUID(obj client.Object) []string {
+	var uids []string
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.APIVersion == certsv1.GroupVersion.String() && owner.Kind == "Certificate" {
+			uids = append(uids, string(owner.UID))
+		}
+	}
+	return uids
+}
+
+// IndexPasswordSecrets returns the names of the secrets holding the passwords of the keystores of the certificate
+func IndexPasswordSecrets(obj client.Object) []string {
+	cert, ok := obj.(*certsv1.Certificate)
+	if !ok || cert.Spec.Keystores == nil {
+		return nil
+	}
+	var names []string
+	if pkcs12 := cert.Spec.Keystores.PKCS12; pkcs12 != nil && pkcs12.Create {
+		names = append(names, pkcs12.PasswordSecretRef.Name)
+	}
+	if jks := cert.Spec.Keystores.JKS; jks != nil && jks.Create && !slices.Contains(names, jks.PasswordSecretRef.Name) {
+		names = append(names, jks.PasswordSecretRef.Name)
+	}
+	return names
+}
+
+// SetManagedLabel labels the secret as managed by certaur, it reports whether the label has been added
+func SetManagedLabel(secret *corev1.Secret) bool {
+	if secret.Labels[ManagedLabel] == "true" {
+		return false
+	}
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Labels[ManagedLabel] = "true"
+	return true
+}
+
+// FallbackClient reads the secrets missing from the cache, which only holds the secrets managed by
+// certaur, with an uncached reader. It gives access to CA and password secrets, which are not
+// labelled, and to secrets created outside of certaur that are adopted or were created before the
+// managed label
+type FallbackClient struct {
+	client.Client
+	Reader client.Reader
+}
+
+// NewFallbackClient returns a client reading secrets missing from the cache of c with reader
+func NewFallbackClient(c client.Client, reader client.Reader) client.Client {
+	return &FallbackClient{Client: c, Reader: reader}
+}
+
+// APIReader returns the uncached reader of a FallbackClient, other clients are returned as is
+func APIReader(c client.Client) client.Reader {
+	if fallback, ok := c.(*FallbackClient); ok {
+		return fallback.Reader
+	}
+	return c
+}
+
+// Get reads the object from the cache, secrets that are not found are read with the uncached reader
+func (c *FallbackClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	err := c.Client.Get(ctx, key, obj, opts...)
+	if _, isSecret := obj.(*corev1.Secret); isSecret && apierrors.IsNotFound(err) {
+		return c.Reader.Get(ctx, key, obj, opts...)
+	}
+	return err
+}
@@ -2,14 +2,22 @@ package secret
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"slices"
 
 	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
 	"github.com/AKI-25/certaur/pkg/util/certificate"
+	"github.com/AKI-25/certaur/pkg/util/keystore"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 func IsOwnerReference(cert *certsv1.Certificate, secret *corev1.Secret) bool {
@@ -21,41 +29,359 @@ func IsOwnerReference(cert *certsv1.Certificate, secret *corev1.Secret) bool {
 	return false
 }
 
-// create a secret for certificate and key storage
-func CreateSecret(req ctrl.Request, Client client.Client, ctx context.Context, cert *certsv1.Certificate, secretName string, crt, key []byte) error {
+// create a secret for certificate and key storage, the CA certificate is stored
+// in ca.crt when the certificate is not self-signed
+func CreateSecret(req ctrl.Request, Client client.Client, ctx context.Context, cert *certsv1.Certificate, secretName string, crt, key []byte, opts certificate.IssueOptions) error {
+	secret, err := newSecret(cert, secretName, req.Namespace, crt, key, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := Client.Create(ctx, secret); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MigrateSecret creates the secret of the certificate with the keypair of its previous secret, after
+// its secretRef has been renamed. The secret is only created when the keypair passes the integrity
+// check of the certificate, nil is returned when the previous secret holds no keypair or a keypair
+// that does not match the certificate
+func MigrateSecret(ctx context.Context, Client client.Client, cert *certsv1.Certificate, previous *corev1.Secret, opts certificate.IssueOptions) (*corev1.Secret, error) {
+	keys := SecretKeys(cert)
+	crt, key := previous.Data[keys.Certificate], previous.Data[keys.PrivateKey]
+	if len(crt) == 0 || len(key) == 0 {
+		return nil, nil
+	}
+	// a keypair that cannot be parsed does not match the certificate either
+	if _, err := certificate.ParseCertificates(crt); err != nil {
+		return nil, nil
+	}
+	if _, err := certificate.ParsePrivateKey(key); err != nil {
+		return nil, nil
+	}
+
+	secret, err := newSecret(cert, cert.Spec.SecretRef.Name, cert.Namespace, crt, key, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build secret from previous Secret %s: %w", previous.Name, err)
+	}
+	ok, err := CheckSecretIntegrity(cert, secret, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check keypair of previous Secret %s: %w", previous.Name, err)
+	} else if !ok {
+		return nil, nil
+	}
+
+	if err := Client.Create(ctx, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// newSecret builds the secret of the certificate holding the given keypair
+func newSecret(cert *certsv1.Certificate, name, namespace string, crt, key []byte, opts certificate.IssueOptions) (*corev1.Secret, error) {
+	data, err := secretData(cert, crt, key, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	secret := &corev1.Secret{
 		ObjectMeta: ctrl.ObjectMeta{
-			Name:      secretName,
-			Namespace: req.Namespace,
+			Name:      name,
+			Namespace: namespace,
 			OwnerReferences: []metav1.OwnerReference{
 				*metav1.NewControllerRef(cert, certsv1.GroupVersion.WithKind("Certificate")),
 			},
 		},
-		Data: map[string][]byte{
-			"tls.crt": crt,
-			"tls.key": key,
-		},
-		Type: corev1.SecretTypeTLS,
+		Data: data,
+		Type: SecretType(cert),
 	}
+	setManagedKeys(secret, data)
+	setPKCS12Profile(cert, secret)
+	SetManagedLabel(secret)
+	applySecretTemplate(cert, secret)
+	return secret, nil
+}
 
-	if err := Client.Create(ctx, secret); err != nil {
+// update already available secret
+
+func UpdateSecret(client client.Client, ctx context.Context, secret *corev1.Secret, cert *certsv1.Certificate, crt, key []byte, opts certificate.IssueOptions) error {
+	if err := CheckSecretType(cert, secret); err != nil {
 		return err
 	}
+
+	data, err := secretData(cert, crt, key, opts)
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for _, k := range managedKeys(secret) {
+		delete(secret.Data, k)
+	}
+	for k, v := range data {
+		secret.Data[k] = v
+	}
+	setManagedKeys(secret, data)
+	setPKCS12Profile(cert, secret)
+	SetManagedLabel(secret)
+	applySecretTemplate(cert, secret)
+
+	return client.Update(ctx, secret)
+}
+
+// ErrSecretTypeChanged is returned for secrets whose type does not match the key names of the certificate
+var ErrSecretTypeChanged = errors.New("secret type does not match the certificate")
+
+// CheckSecretType returns ErrSecretTypeChanged when the key names of the certificate require another type
+// than the one of the secret. The type of a secret is immutable, such a secret is replaced by RecreateSecret
+func CheckSecretType(cert *certsv1.Certificate, secret *corev1.Secret) error {
+	if secretType := SecretType(cert); secret.Type != secretType {
+		return fmt.Errorf("%w: Secret %s is of type %s, delete it to have it recreated as %s", ErrSecretTypeChanged, secret.Name, secret.Type, secretType)
+	}
 	return nil
 }
 
-// update already available secret
+// RecreateSecret replaces the secret of the certificate by a secret of the type matching the key names of
+// the certificate, since the type of a secret cannot be updated. A new certificate is issued into the new
+// secret, whose PEM encoded certificate is returned
+func RecreateSecret(ctx context.Context, Client client.Client, cert *certsv1.Certificate, secret *corev1.Secret, opts certificate.IssueOptions) ([]byte, error) {
+	opts.PrivateKey = reusableKey(cert, secret)
+	certPEM, keyPEM, err := certificate.GenerateTLSCertificate(&cert.Spec, opts)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := newSecret(cert, secret.Name, secret.Namespace, certPEM, keyPEM, opts)
+	if err != nil {
+		return nil, err
+	}
 
-func UpdateSecret(client client.Client, ctx context.Context, secret *corev1.Secret, cert, key []byte) error {
-	secret.Data["tls.crt"] = cert
-	secret.Data["tls.key"] = key
+	if err := Client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to delete Secret %s of type %s: %w", secret.Name, secret.Type, err)
+	}
+	if err := Client.Create(ctx, replacement); err != nil {
+		return nil, fmt.Errorf("failed to recreate Secret %s as %s: %w", secret.Name, replacement.Type, err)
+	}
+	return certPEM, nil
+}
 
-	return client.Update(ctx, secret)
+// secretData returns the data stored in the secret of the certificate: the certificate and its key,
+// the CA certificate when the certificate is not self-signed, the additional output formats
+// and the requested keystores
+func secretData(cert *certsv1.Certificate, crt, key []byte, opts certificate.IssueOptions) (map[string][]byte, error) {
+	keys := SecretKeys(cert)
+	data := map[string][]byte{
+		keys.Certificate: crt,
+		keys.PrivateKey:  key,
+	}
+	var ca []byte
+	if opts.CA != nil {
+		ca = opts.CA.RootCertificatePEM()
+		data[keys.CA] = ca
+	}
+
+	outputs, err := outputFormatData(cert, crt, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode additional output formats: %w", err)
+	}
+	for k, v := range outputs {
+		data[k] = v
+	}
+
+	keystores, err := keystoreData(cert, crt, key, ca, opts.KeystorePasswords)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode keystores: %w", err)
+	}
+	for k, v := range keystores {
+		data[k] = v
+	}
+	return data, nil
+}
+
+// KeystorePasswords reads the passwords of the keystores requested by the certificate
+func KeystorePasswords(ctx context.Context, Client client.Client, cert *certsv1.Certificate) (keystore.Passwords, error) {
+	var passwords keystore.Passwords
+	keystores := cert.Spec.Keystores
+	if keystores == nil {
+		return passwords, nil
+	}
+
+	var err error
+	if keystores.PKCS12 != nil && keystores.PKCS12.Create {
+		if passwords.PKCS12, err = readSecretKey(ctx, Client, cert.Namespace, keystores.PKCS12.PasswordSecretRef); err != nil {
+			return passwords, err
+		}
+	}
+	if keystores.JKS != nil && keystores.JKS.Create {
+		if passwords.JKS, err = readSecretKey(ctx, Client, cert.Namespace, keystores.JKS.PasswordSecretRef); err != nil {
+			return passwords, err
+		}
+	}
+	return passwords, nil
+}
+
+// read the value of a key of a password secret. Password secrets are not owned by certaur, they are
+// read with the uncached reader rather than labelled to be held in the cache, and watched through
+// their metadata
+func readSecretKey(ctx context.Context, Client client.Client, namespace string, ref certsv1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := APIReader(Client).Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to get password secret %s: %w", ref.Name, err)
+	}
+	value, exists := secret.Data[ref.Key]
+	if !exists {
+		return "", fmt.Errorf("password secret %s does not contain key %s", ref.Name, ref.Key)
+	}
+	return string(value), nil
+}
+
+// keystoreMaterial parses the PEM encoded certificate chain, private key and CA certificates
+func keystoreMaterial(crt, key, ca []byte) (crypto.Signer, []*x509.Certificate, []*x509.Certificate, error) {
+	chain, err := certificate.ParseCertificates(crt)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	privateKey, err := certificate.ParsePrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var cas []*x509.Certificate
+	if len(ca) != 0 {
+		if cas, err = certificate.ParseCertificates(ca); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return privateKey, chain, cas, nil
 }
 
+// keystoreData encodes the keystores requested by the certificate from the PEM encoded
+// certificate chain, private key and CA certificate
+func keystoreData(cert *certsv1.Certificate, crt, key, ca []byte, passwords keystore.Passwords) (map[string][]byte, error) {
+	keystores := map[string][]byte{}
+	spec := cert.Spec.Keystores
+	if spec == nil {
+		return keystores, nil
+	}
+
+	privateKey, chain, cas, err := keystoreMaterial(crt, key, ca)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile := pkcs12Profile(cert); profile != "" {
+		if keystores[keystore.PKCS12KeystoreKey], err = keystore.EncodePKCS12(privateKey, chain, passwords.PKCS12, profile); err != nil {
+			return nil, err
+		}
+		if len(cas) != 0 {
+			if keystores[keystore.PKCS12TruststoreKey], err = keystore.EncodePKCS12TrustStore(cas, passwords.PKCS12, profile); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if spec.JKS != nil && spec.JKS.Create {
+		if keystores[keystore.JKSKeystoreKey], err = keystore.EncodeJKSKeystore(privateKey, chain, passwords.JKS); err != nil {
+			return nil, err
+		}
+		if len(cas) != 0 {
+			if keystores[keystore.JKSTruststoreKey], err = keystore.EncodeJKSTrustStore(cas, passwords.JKS); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return keystores, nil
+}
+
+// pkcs12Profile returns the profile of the PKCS#12 keystores requested by the certificate,
+// empty when no PKCS#12 keystore is requested
+func pkcs12Profile(cert *certsv1.Certificate) string {
+	keystores := cert.Spec.Keystores
+	if keystores == nil || keystores.PKCS12 == nil || !keystores.PKCS12.Create {
+		return ""
+	}
+	if keystores.PKCS12.Profile == "" {
+		return keystore.DefaultPKCS12Profile
+	}
+	return string(keystores.PKCS12.Profile)
+}
+
+// setPKCS12Profile records the profile of the PKCS#12 keystores of the secret
+func setPKCS12Profile(cert *certsv1.Certificate, secret *corev1.Secret) {
+	if profile := pkcs12Profile(cert); profile != "" {
+		secret.Annotations[PKCS12ProfileAnnotation] = profile
+	} else {
+		delete(secret.Annotations, PKCS12ProfileAnnotation)
+	}
+}
+
+// storedPKCS12Profile returns the profile the PKCS#12 keystores of the secret are encoded with,
+// keystores written before the profile was recorded use the default profile
+func storedPKCS12Profile(secret *corev1.Secret) string {
+	if profile, exists := secret.Annotations[PKCS12ProfileAnnotation]; exists {
+		return profile
+	}
+	return keystore.DefaultPKCS12Profile
+}
+
+// checkKeystores reports whether the keystores stored in the secret are the ones requested by
+// the certificate and hold the certificate, key and CA of the secret
+func checkKeystores(cert *certsv1.Certificate, secret *corev1.Secret, passwords keystore.Passwords) (bool, error) {
+	spec := cert.Spec.Keystores
+	if spec == nil {
+		spec = &certsv1.CertificateKeystores{}
+	}
+	pkcs12Enabled := spec.PKCS12 != nil && spec.PKCS12.Create
+	jksEnabled := spec.JKS != nil && spec.JKS.Create
+
+	// The keystores are encoded again when the PKCS#12 profile has changed
+	if pkcs12Enabled && storedPKCS12Profile(secret) != pkcs12Profile(cert) {
+		return false, nil
+	}
+
+	keys := SecretKeys(cert)
+	key, chain, cas, err := keystoreMaterial(secret.Data[keys.Certificate], secret.Data[keys.PrivateKey], secret.Data[keys.CA])
+	if err != nil {
+		// a chain or CA that cannot be parsed is regenerated
+		return false, nil
+	}
+
+	checks := []struct {
+		key     string
+		enabled bool
+		check   func(data []byte) bool
+	}{
+		{keystore.PKCS12KeystoreKey, pkcs12Enabled, func(data []byte) bool {
+			return keystore.CheckPKCS12(data, passwords.PKCS12, key, chain)
+		}},
+		{keystore.PKCS12TruststoreKey, pkcs12Enabled && len(cas) != 0, func(data []byte) bool {
+			return keystore.CheckPKCS12TrustStore(data, passwords.PKCS12, cas)
+		}},
+		{keystore.JKSKeystoreKey, jksEnabled, func(data []byte) bool {
+			return keystore.CheckJKSKeystore(data, passwords.JKS, key, chain)
+		}},
+		{keystore.JKSTruststoreKey, jksEnabled && len(cas) != 0, func(data []byte) bool {
+			return keystore.CheckJKSTrustStore(data, passwords.JKS, cas)
+		}},
+	}
+	for _, c := range checks {
+		data, exists := secret.Data[c.key]
+		if exists != c.enabled || (exists && !c.check(data)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// CheckOwnership returns the secrets owned by the certificate besides the one referenced by its secretRef,
+// they are looked up among the managed secrets of its namespace through the owner UID index
 func CheckOwnership(ctx context.Context, Client client.Client, cert *certsv1.Certificate) (corev1.SecretList, error) {
 	var secretList, ownedSecrets corev1.SecretList
-	err := Client.List(ctx, &secretList)
+	err := Client.List(ctx, &secretList,
+		client.InNamespace(cert.Namespace),
+		client.MatchingLabelsSelector{Selector: ManagedSelector()},
+		client.MatchingFields{OwnerUIDIndex: string(cert.UID)},
+	)
 	if err != nil {
 		return corev1.SecretList{}, err
 	}
@@ -77,62 +403,149 @@ func DeleteSecrets(ctx context.Context, Client client.Client, secretList *corev1
 	return nil
 }
 
-func EnsureSecretIntegrity(ctx context.Context, Client client.Client, cert *certsv1.Certificate, secret *corev1.Secret) error {
+func EnsureSecretIntegrity(ctx context.Context, Client client.Client, cert *certsv1.Certificate, secret *corev1.Secret, opts certificate.IssueOptions) error {
 	// Generate TLS certificate
-
-	certPEM, keyPEM, err := certificate.GenerateTLSCertificate(cert.Spec.DnsName, cert.Spec.Validity)
+	opts.PrivateKey = reusableKey(cert, secret)
+	certPEM, keyPEM, err := certificate.GenerateTLSCertificate(&cert.Spec, opts)
 	if err != nil {
 		return err
 	}
+
 	// Update the secret with the latest certificate and key
-	err = UpdateSecret(Client, ctx, secret, certPEM, keyPEM)
+	err = UpdateSecret(Client, ctx, secret, cert, certPEM, keyPEM, opts)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func FindAndDeletePreviousSecrets(ctx context.Context, Client client.Client, cert *certsv1.Certificate) error {
+// reusableKey returns the private key stored in the secret when the rotation policy of the
+// certificate is Never and the key still matches the requested algorithm, nil otherwise
+func reusableKey(cert *certsv1.Certificate, secret *corev1.Secret) crypto.Signer {
+	if certificate.RotationPolicy(cert.Spec.PrivateKey) != certsv1.NeverRotationPolicy {
+		return nil
+	}
+	key, err := certificate.ParsePrivateKey(secret.Data[SecretKeys(cert).PrivateKey])
+	if err != nil || !certificate.CheckKeyAlgorithm(key, cert.Spec.PrivateKey) {
+		return nil
+	}
+	return key
+}
+
+// FindAndDeletePreviousSecrets deletes the secrets owned by the certificate besides the one referenced by
+// its secretRef, except the secrets named in keep, which are returned by name when they exist
+func FindAndDeletePreviousSecrets(ctx context.Context, Client client.Client, cert *certsv1.Certificate, keep ...string) (map[string]*corev1.Secret, error) {
 	ownedSecrets, err := CheckOwnership(ctx, Client, cert)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	kept := map[string]*corev1.Secret{}
+	var previousSecrets corev1.SecretList
+	for i := range ownedSecrets.Items {
+		if name := ownedSecrets.Items[i].Name; slices.Contains(keep, name) {
+			kept[name] = &ownedSecrets.Items[i]
+			continue
+		}
+		previousSecrets.Items = append(previousSecrets.Items, ownedSecrets.Items[i])
 	}
 
-	if len(ownedSecrets.Items) != 0 {
-		fmt.Println("Certificate already owns a secret")
-		// delete previously owned secrets
-		err := DeleteSecrets(ctx, Client, &ownedSecrets)
+	if len(previousSecrets.Items) != 0 {
+		log.FromContext(ctx).Info("Deleting previous secrets owned by the certificate", "CertificateName", cert.Name, "Count", len(previousSecrets.Items))
+		err := DeleteSecrets(ctx, Client, &previousSecrets)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return kept, nil
 }
 
-func CheckSecretIntegrity(cert *certsv1.Certificate, secret *corev1.Secret) (bool, error) {
-	parsedCert, err := certificate.ExtractCertData(*secret)
+// CheckSecretIntegrity reports whether the secret holds a certificate matching the Certificate CR
+// that has been issued with the given options. Material that cannot be parsed, such as the one of an
+// adopted secret, does not match the certificate and is regenerated
+func CheckSecretIntegrity(cert *certsv1.Certificate, secret *corev1.Secret, opts certificate.IssueOptions) (bool, error) {
+	// Check if the secret stores the certificate and its key under the key names and the type
+	// requested in the Certificate CR
+	keys := SecretKeys(cert)
+	keyData, exists := secret.Data[keys.PrivateKey]
+	if _, crtExists := secret.Data[keys.Certificate]; !crtExists || !exists || secret.Type != SecretType(cert) {
+		return false, nil
+	}
+
+	parsedCert, err := ExtractCertData(cert, secret)
+	if err != nil {
+		return false, nil
+	}
+	// Check if the certificate has been signed by the issuer of the Certificate CR
+	if !certificate.CheckCertIssuer(parsedCert, secret.Data[keys.Certificate], opts.CA, secret.Data[keys.CA]) {
+		return false, nil
+	}
+	// Check if the CA constraints match the ones requested in the Certificate CR
+	if !certificate.CheckCertCAConstraints(parsedCert, &cert.Spec) {
+		return false, nil
+	}
+	// Check if the subject alternative names match the ones requested in the Certificate CR
+	ok, err := certificate.CheckCertSANs(parsedCert, &cert.Spec)
+	if err != nil {
+		return false, err
+	} else if !ok {
+		return ok, err
+	}
+
+	// Check if the subject matches the one requested in the Certificate CR
+	if !certificate.CheckCertSubject(parsedCert, &cert.Spec) {
+		return false, nil
+	}
+
+	// Check if the certificate expiration date matches the validity or duration requested in the Certificate CR
+	duration, err := certificate.CertificateDuration(&cert.Spec)
 	if err != nil {
 		return false, err
 	}
-	// Check if the Common Name matches the dnsName field in the Certificate CR
-	if err := parsedCert.VerifyHostname(cert.Spec.DnsName); err != nil {
+	// a certificate cut short at the NotAfter of its CA is not expected to last the whole duration
+	if opts.CA.Clamped(parsedCert.NotAfter) {
+		duration = min(duration, parsedCert.NotAfter.Sub(parsedCert.NotBefore)-opts.Backdate)
+	}
+	if !certificate.CheckCertValidity(parsedCert.NotBefore, parsedCert.NotAfter, duration, opts.Backdate) {
 		return false, nil
 	}
 
-	// Check if the certificate expiration date matches the validity field in the Certificate CR
-	ok, err := certificate.CheckCertValidity(parsedCert.NotBefore, parsedCert.NotAfter, cert.Spec.Validity)
+	privateKey, err := certificate.ParsePrivateKey(keyData)
+	if err != nil {
+		return false, nil
+	}
+
+	// Check if the private key uses the algorithm and encoding requested in the Certificate CR
+	if !certificate.CheckKeyAlgorithm(privateKey, cert.Spec.PrivateKey) ||
+		!certificate.CheckKeyEncoding(keyData, cert.Spec.PrivateKey) {
+		return false, nil
+	}
+
+	// Check if the key usages match the ones requested in the Certificate CR
+	ok, err = certificate.CheckCertUsages(parsedCert, &cert.Spec, privateKey)
+	if err != nil {
+		return false, err
+	} else if !ok {
+		return ok, err
+	}
+
+	ok, err = certificate.CheckCertKey(parsedCert, privateKey)
 	if err != nil {
 		return false, err
 	} else if !ok {
 		return ok, err
 	}
 
-	privateKey, err := certificate.ExtractKeyData(*secret)
+	// Check if the additional output formats match the ones requested in the Certificate CR
+	ok, err = checkOutputFormats(cert, secret)
 	if err != nil {
 		return false, err
+	} else if !ok {
+		return ok, err
 	}
 
-	ok, err = certificate.CheckCertKey(&parsedCert, &privateKey)
+	// Check if the keystores match the ones requested in the Certificate CR and hold tls.crt
+	ok, err = checkKeystores(cert, secret, opts.KeystorePasswords)
 	if err != nil {
 		return false, err
 	} else if !ok {
@@ -141,11 +554,3 @@ func CheckSecretIntegrity(cert *certsv1.Certificate, secret *corev1.Secret) (boo
 
 	return ok, nil
 }
-
-// func displaySecrets(secretList *corev1.SecretList) []string {
-// 	var secretNames []string
-// 	for _, secret := range secretList.Items {
-// 		secretNames = append(secretNames, secret.Name)
-// 	}
-// 	return secretNames
-// }
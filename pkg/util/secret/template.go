@@ -0,0 +1,247 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	"github.com/AKI-25/certaur/pkg/util/certificate"
+	"github.com/AKI-25/certaur/pkg/util/keystore"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CAKey is the default key of the CA certificate in the secret
+	CAKey = "ca.crt"
+	// CombinedPEMKey is the key of the private key followed by the certificate chain
+	CombinedPEMKey = "tls-combined.pem"
+	// DERKey is the key of the private key in DER form
+	DERKey = "key.der"
+
+	// ManagedKeysAnnotation lists the keys of the secret written by certaur, which are
+	// removed from the secret once they are no longer requested by the certificate
+	ManagedKeysAnnotation = "certs.k8c.io/managed-keys"
+	// ManagedLabelsAnnotation and ManagedAnnotationsAnnotation list the labels and annotations
+	// of the secret set from the secret template, which are removed from the secret once they
+	// are no longer in the template
+	ManagedLabelsAnnotation      = "certs.k8c.io/managed-labels"
+	ManagedAnnotationsAnnotation = "certs.k8c.io/managed-annotations"
+	// PKCS12ProfileAnnotation records the profile the PKCS#12 keystores of the secret are encoded with,
+	// so that a change of the profile of the certificate is detected
+	PKCS12ProfileAnnotation = "certs.k8c.io/pkcs12-profile"
+)
+
+// ReservedKeys are the keys written for additional output formats and keystores,
+// they cannot be used as custom key names
+var ReservedKeys = []string{
+	CombinedPEMKey,
+	DERKey,
+	keystore.PKCS12KeystoreKey,
+	keystore.PKCS12TruststoreKey,
+	keystore.JKSKeystoreKey,
+	keystore.JKSTruststoreKey,
+}
+
+// keys written by certaur to secrets created before the managed keys annotation
+var defaultManagedKeys = append([]string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, CAKey}, ReservedKeys...)
+
+// SecretKeys returns the keys of the secret holding the certificate, its private key and the CA certificate
+func SecretKeys(cert *certsv1.Certificate) certsv1.CertificateSecretKeys {
+	keys := certsv1.CertificateSecretKeys{
+		Certificate: corev1.TLSCertKey,
+		PrivateKey:  corev1.TLSPrivateKeyKey,
+		CA:          CAKey,
+	}
+	if cert.Spec.SecretTemplate == nil || cert.Spec.SecretTemplate.Keys == nil {
+		return keys
+	}
+
+	custom := cert.Spec.SecretTemplate.Keys
+	if custom.Certificate != "" {
+		keys.Certificate = custom.Certificate
+	}
+	if custom.PrivateKey != "" {
+		keys.PrivateKey = custom.PrivateKey
+	}
+	if custom.CA != "" {
+		keys.CA = custom.CA
+	}
+	return keys
+}
+
+// ExtractCertData parses the certificate stored in the secret of the certificate
+func ExtractCertData(cert *certsv1.Certificate, secret *corev1.Secret) (*x509.Certificate, error) {
+	key := SecretKeys(cert).Certificate
+	certData, exists := secret.Data[key]
+	if !exists {
+		return nil, fmt.Errorf("secret does not contain %s field", key)
+	}
+	return certificate.ParseCertificate(certData)
+}
+
+// ExtractKeyData parses the private key stored in the secret of the certificate
+func ExtractKeyData(cert *certsv1.Certificate, secret *corev1.Secret) (crypto.Signer, error) {
+	key := SecretKeys(cert).PrivateKey
+	keyData, exists := secret.Data[key]
+	if !exists {
+		return nil, fmt.Errorf("secret does not contain %s field", key)
+	}
+	return certificate.ParsePrivateKey(keyData)
+}
+
+// SecretType returns kubernetes.io/tls when the certificate and its private key are stored
+// under the default keys, Opaque otherwise
+func SecretType(cert *certsv1.Certificate) corev1.SecretType {
+	keys := SecretKeys(cert)
+	if keys.Certificate == corev1.TLSCertKey && keys.PrivateKey == corev1.TLSPrivateKeyKey {
+		return corev1.SecretTypeTLS
+	}
+	return corev1.SecretTypeOpaque
+}
+
+// outputFormatData encodes the additional output formats requested by the certificate
+func outputFormatData(cert *certsv1.Certificate, crt, key []byte) (map[string][]byte, error) {
+	outputs := map[string][]byte{}
+	if cert.Spec.SecretTemplate == nil {
+		return outputs, nil
+	}
+
+	for _, format := range cert.Spec.SecretTemplate.AdditionalOutputFormats {
+		switch format.Type {
+		case certsv1.CombinedPEMOutputFormat:
+			outputs[CombinedPEMKey] = append(append([]byte{}, key...), crt...)
+		case certsv1.DEROutputFormat:
+			block, _ := pem.Decode(key)
+			if block == nil {
+				return nil, errors.New("failed to decode PEM block containing the private key")
+			}
+			outputs[DERKey] = block.Bytes
+		}
+	}
+	return outputs, nil
+}
+
+// checkOutputFormats reports whether the additional output formats stored in the secret are the
+// ones requested by the certificate and hold the certificate and private key of the secret
+func checkOutputFormats(cert *certsv1.Certificate, secret *corev1.Secret) (bool, error) {
+	keys := SecretKeys(cert)
+	expected, err := outputFormatData(cert, secret.Data[keys.Certificate], secret.Data[keys.PrivateKey])
+	if err != nil {
+		return false, err
+	}
+
+	for _, key := range []string{CombinedPEMKey, DERKey} {
+		data, exists := secret.Data[key]
+		want, requested := expected[key]
+		if exists != requested || !bytes.Equal(data, want) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// managedKeys returns the keys of the secret written by certaur
+func managedKeys(secret *corev1.Secret) []string {
+	annotation, exists := secret.Annotations[ManagedKeysAnnotation]
+	if !exists {
+		return defaultManagedKeys
+	}
+	return splitKeys(annotation)
+}
+
+// setManagedKeys records the keys of the data written by certaur in the secret
+func setManagedKeys(secret *corev1.Secret, data map[string][]byte) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[ManagedKeysAnnotation] = joinKeys(data)
+}
+
+// applySecretTemplate sets the labels and annotations of the secret template on the secret and
+// removes the ones previously set from the template that are no longer in it. It reports whether
+// the secret has been changed
+func applySecretTemplate(cert *certsv1.Certificate, secret *corev1.Secret) bool {
+	var labels, annotations map[string]string
+	if template := cert.Spec.SecretTemplate; template != nil {
+		labels, annotations = template.Labels, template.Annotations
+	}
+
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	changed := syncMetadata(secret.Labels, labels, secret.Annotations, ManagedLabelsAnnotation)
+	changed = syncMetadata(secret.Annotations, annotations, secret.Annotations, ManagedAnnotationsAnnotation) || changed
+	return changed
+}
+
+// syncMetadata sets the desired entries on the current labels or annotations, removes the entries
+// listed in the managed annotation that are no longer desired and records the desired keys in the
+// managed annotation. It reports whether anything has been changed
+func syncMetadata(current, desired, annotations map[string]string, managedAnnotation string) bool {
+	changed := false
+	for _, key := range splitKeys(annotations[managedAnnotation]) {
+		if _, keep := desired[key]; keep {
+			continue
+		}
+		if _, exists := current[key]; exists {
+			delete(current, key)
+			changed = true
+		}
+	}
+	for key, value := range desired {
+		if existing, exists := current[key]; !exists || existing != value {
+			current[key] = value
+			changed = true
+		}
+	}
+
+	managed := joinKeys(desired)
+	if annotations[managedAnnotation] != managed {
+		if managed == "" {
+			delete(annotations, managedAnnotation)
+		} else {
+			annotations[managedAnnotation] = managed
+		}
+		changed = true
+	}
+	return changed
+}
+
+// SyncSecretTemplate applies the labels and annotations of the secret template and the managed label
+// to the secret, the secret is only updated when they are out of sync
+func SyncSecretTemplate(ctx context.Context, Client client.Client, cert *certsv1.Certificate, secret *corev1.Secret) (bool, error) {
+	labelled := SetManagedLabel(secret)
+	if !applySecretTemplate(cert, secret) && !labelled {
+		return false, nil
+	}
+	return true, Client.Update(ctx, secret)
+}
+
+// joinKeys returns the sorted keys of the map as a comma separated list
+func joinKeys[V any](m map[string]V) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+// splitKeys returns the keys of a comma separated list
+func splitKeys(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
@@ -4,14 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
 
 	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	configv1alpha1 "github.com/AKI-25/certaur/pkg/config/v1alpha1"
+	certificateutil "github.com/AKI-25/certaur/pkg/util/certificate"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,12 +36,15 @@ import (
 type Validator struct {
 	client client.Client
 	scheme *runtime.Scheme
+	// Config holds the defaults, lifetime limits and feature gates applied to certificates,
+	// the default configuration is used when it is nil
+	Config *configv1alpha1.CertaurConfiguration
 }
 
-var (
-	dnsNameRegex  = `^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,6}$`
-	validityRegex = `^\d+d$`
-)
+var countryRegex = `^[A-Z]{2}$`
+
+// upper bound of the common name length defined in RFC 5280
+const maxCommonNameLength = 64
 
 // log is for logging in this package.
 var certificatelog = logf.Log.WithName("certificate-resource")
@@ -42,8 +56,9 @@ func (v Validator) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 	// instantiate a Validator
 	certificateValidator := &Validator{
-		client: mgr.GetClient(),
+		client: secretutil.NewFallbackClient(mgr.GetClient(), mgr.GetAPIReader()),
 		scheme: mgr.GetScheme(),
+		Config: v.Config,
 	}
 
 	// register the webhook with the manager.
@@ -67,15 +82,54 @@ func (v *Validator) Default(ctx context.Context, obj runtime.Object) error {
 
 	certificatelog.Info("default", "name", cert.Name)
 
+	v.defaultDNSNames(cert)
 	v.defaultValidity(cert)
+	v.defaultPrivateKey(cert)
+	v.defaultIssuerRef(cert)
 	v.defaultSecretName(cert)
+	v.defaultSecretDeletionPolicy(cert)
+	v.defaultRenewBefore(cert)
 
 	return nil
 }
 
+// config returns the configuration of the validator, falling back to the default configuration
+func (v *Validator) config() *configv1alpha1.CertaurConfiguration {
+	if v.Config == nil {
+		return configv1alpha1.New()
+	}
+	return v.Config
+}
+
+// merge the legacy dnsName field into dnsNames so that both are always in sync
+func (v *Validator) defaultDNSNames(cert *certsv1.Certificate) {
+	cert.Spec.DNSNames = certificateutil.DNSNames(&cert.Spec)
+}
+
 func (v *Validator) defaultValidity(cert *certsv1.Certificate) {
-	if cert.Spec.Validity == "" {
-		cert.Spec.Validity = "365d"
+	if cert.Spec.Validity == "" && cert.Spec.Duration == nil {
+		cert.Spec.Validity = v.config().Defaults.Validity
+	}
+}
+
+func (v *Validator) defaultPrivateKey(cert *certsv1.Certificate) {
+	if cert.Spec.PrivateKey == nil {
+		cert.Spec.PrivateKey = &certsv1.CertificatePrivateKey{}
+	}
+	// the default key only applies to certificates that specify neither an algorithm nor a size,
+	// a size without algorithm keeps referring to an RSA key
+	if cert.Spec.PrivateKey.Algorithm == "" && cert.Spec.PrivateKey.Size == 0 {
+		cert.Spec.PrivateKey.Algorithm = v.config().Defaults.PrivateKey.Algorithm
+		cert.Spec.PrivateKey.Size = v.config().Defaults.PrivateKey.Size
+	}
+	cert.Spec.PrivateKey.Algorithm, cert.Spec.PrivateKey.Size = certificateutil.KeyAlgorithm(cert.Spec.PrivateKey)
+	cert.Spec.PrivateKey.Encoding = certificateutil.KeyEncoding(cert.Spec.PrivateKey)
+	cert.Spec.PrivateKey.RotationPolicy = certificateutil.RotationPolicy(cert.Spec.PrivateKey)
+}
+
+func (v *Validator) defaultIssuerRef(cert *certsv1.Certificate) {
+	if cert.Spec.IssuerRef != nil && cert.Spec.IssuerRef.Kind == "" {
+		cert.Spec.IssuerRef.Kind = certsv1.IssuerKind
 	}
 }
 
@@ -85,34 +139,38 @@ func (v *Validator) defaultSecretName(cert *certsv1.Certificate) {
 	}
 }
 
+func (v *Validator) defaultSecretDeletionPolicy(cert *certsv1.Certificate) {
+	if cert.Spec.SecretDeletionPolicy == "" {
+		cert.Spec.SecretDeletionPolicy = v.config().Defaults.SecretDeletionPolicy
+	}
+}
+
+// certificates are renewed after two thirds of their lifetime unless a renewal default is configured
+func (v *Validator) defaultRenewBefore(cert *certsv1.Certificate) {
+	percentage := v.config().Renewal.RenewBeforePercentage
+	if percentage == nil || cert.Spec.RenewBefore != nil || cert.Spec.RenewBeforePercentage != nil {
+		return
+	}
+	renewBeforePercentage := *percentage
+	cert.Spec.RenewBeforePercentage = &renewBeforePercentage
+}
+
 // implement a custom validator
 
 var _ admission.CustomValidator = &Validator{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (v *Validator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
-	var allErrs []string
-
 	cert, ok := obj.(*certsv1.Certificate)
 	if !ok {
-		allErrs = append(allErrs, fmt.Sprintf("unexpected type: %T", obj))
+		return []string{
+			fmt.Sprintf("unexpected type: %T", obj),
+		}, nil
 	}
-	certificatelog.Info("validate create", "name", cert.Name)
 
-	if err := validateDNSName(cert); err != nil {
-		allErrs = append(allErrs, err.Error())
-	}
-	if err := validateValidity(cert); err != nil {
-		allErrs = append(allErrs, err.Error())
-	}
-	if err := validateSecretName(v.client, cert); err != nil {
-		allErrs = append(allErrs, err.Error())
-	}
+	certificatelog.Info("validate create", "name", cert.Name)
 
-	if len(allErrs) == 0 {
-		return nil, nil
-	}
-	return allErrs, errors.New("failed to create resource")
+	return v.validate(cert, nil, "failed to create resource")
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -123,10 +181,80 @@ func (v *Validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.O
 			fmt.Sprintf("unexpected type: %T", newObj),
 		}, nil
 	}
+	old, ok := oldObj.(*certsv1.Certificate)
+	if !ok {
+		return []string{
+			fmt.Sprintf("unexpected type: %T", oldObj),
+		}, nil
+	}
 
 	certificatelog.Info("validate update", "name", cert.Name)
 
-	return v.ValidateCreate(ctx, newObj)
+	// a certificate being deleted is only updated to remove its finalizers, which must not be blocked
+	// by its secret having been orphaned or by a configuration changed since its creation
+	if !cert.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return v.validate(cert, old, "failed to update resource")
+}
+
+// validate checks the certificate, old is the certificate before the update and nil on creation.
+// The checks depending on the configuration or on the existing secret only apply to the fields
+// changed by an update, so that certificates accepted before stay updatable
+func (v *Validator) validate(cert, old *certsv1.Certificate, message string) (admission.Warnings, error) {
+	var allErrs []string
+
+	if err := validateSubjectAltNames(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if err := validateDNSName(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if err := validateSubject(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if old == nil || cert.Spec.Validity != old.Spec.Validity || !equality.Semantic.DeepEqual(cert.Spec.Duration, old.Spec.Duration) {
+		if err := v.validateDuration(cert); err != nil {
+			allErrs = append(allErrs, err.Error())
+		}
+	}
+	if err := validateRenewBefore(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if err := validatePrivateKey(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if err := validateUsages(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if err := validateCA(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if err := validateKeystores(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if old == nil || !equality.Semantic.DeepEqual(cert.Spec.Keystores, old.Spec.Keystores) {
+		if err := v.validateFeatureGates(cert); err != nil {
+			allErrs = append(allErrs, err.Error())
+		}
+	}
+	if err := validateSecretTemplate(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if err := validateIssuerRef(cert); err != nil {
+		allErrs = append(allErrs, err.Error())
+	}
+	if old == nil || cert.Spec.SecretRef.Name != old.Spec.SecretRef.Name {
+		if err := validateSecretName(v.client, cert, v.config().FeatureGates.Enabled(configv1alpha1.SecretAdoptionFeature)); err != nil {
+			allErrs = append(allErrs, err.Error())
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil, nil
+	}
+	return allErrs, errors.New(message)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -134,41 +262,424 @@ func (v *Validator) ValidateDelete(ctx context.Context, obj runtime.Object) (adm
 	return nil, nil
 }
 
+// checks that at least one subject alternative name is requested and that
+// the IP address, URI and email entries are well formed
+func validateSubjectAltNames(c *certsv1.Certificate) error {
+	specPath := field.NewPath("spec")
+	if c.Spec.DnsName == "" && len(c.Spec.DNSNames) == 0 && len(c.Spec.IPAddresses) == 0 &&
+		len(c.Spec.URIs) == 0 && len(c.Spec.EmailAddresses) == 0 && !isNamedCA(c) {
+		return field.Required(specPath.Child("dnsNames"), "at least one subject alternative name is required")
+	}
+
+	var allErrs field.ErrorList
+	for i, addr := range c.Spec.IPAddresses {
+		if net.ParseIP(addr) == nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("ipAddresses").Index(i), addr, "invalid IP address"))
+		}
+	}
+	for i, rawURI := range c.Spec.URIs {
+		if uri, err := url.Parse(rawURI); err != nil || !uri.IsAbs() {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("uris").Index(i), rawURI, "invalid URI, must be absolute"))
+		}
+	}
+	for i, email := range c.Spec.EmailAddresses {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("emailAddresses").Index(i), email, "invalid email address"))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
 func validateDNSName(c *certsv1.Certificate) error {
-	match, _ := regexp.MatchString(dnsNameRegex, c.Spec.DnsName)
-	if !match {
-		return field.Invalid(field.NewPath("spec").Child("dnsName"), c.Spec.DnsName, "invalid DNS name")
+	specPath := field.NewPath("spec")
+
+	var allErrs field.ErrorList
+	if c.Spec.DnsName != "" {
+		if !isDNSName(c.Spec.DnsName) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("dnsName"), c.Spec.DnsName, "invalid DNS name"))
+		}
+	}
+	for i, name := range c.Spec.DNSNames {
+		if !isDNSName(name) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("dnsNames").Index(i), name, "invalid DNS name"))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+// reports whether the name is a DNS subdomain, such as a service name, optionally prefixed with a wildcard label
+func isDNSName(name string) bool {
+	return isDNSSubdomain(strings.TrimPrefix(name, "*."))
+}
+
+// DNS names are case insensitive, they are checked in lower case
+func isDNSSubdomain(name string) bool {
+	return len(validation.IsDNS1123Subdomain(strings.ToLower(name))) == 0
+}
+
+// checks that the common name fits in the certificate and is one of the
+// subject alternative names, and that countries are ISO 3166 codes
+func validateSubject(c *certsv1.Certificate) error {
+	if c.Spec.Subject == nil {
+		return nil
+	}
+
+	subjectPath := field.NewPath("spec").Child("subject")
+	var allErrs field.ErrorList
+
+	commonName := c.Spec.Subject.CommonName
+	if len(commonName) > maxCommonNameLength {
+		allErrs = append(allErrs, field.TooLong(subjectPath.Child("commonName"), commonName, maxCommonNameLength))
+	}
+	if commonName != "" && !c.Spec.IsCA && !isSubjectAltName(c, commonName) {
+		allErrs = append(allErrs, field.Invalid(subjectPath.Child("commonName"), commonName, "common name must be one of the subject alternative names"))
+	}
+	for i, country := range c.Spec.Subject.Countries {
+		if match, _ := regexp.MatchString(countryRegex, country); !match {
+			allErrs = append(allErrs, field.Invalid(subjectPath.Child("countries").Index(i), country, "country must be a two-letter ISO 3166 code"))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+// reports whether the certificate is a CA identified by its common name,
+// which does not need any subject alternative name
+func isNamedCA(c *certsv1.Certificate) bool {
+	return c.Spec.IsCA && c.Spec.Subject != nil && c.Spec.Subject.CommonName != ""
+}
+
+// checks that CA constraints are only set on CA certificates and that the
+// name constraints are valid DNS domains
+func validateCA(c *certsv1.Certificate) error {
+	specPath := field.NewPath("spec")
+
+	var allErrs field.ErrorList
+	if !c.Spec.IsCA {
+		if c.Spec.MaxPathLen != nil {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("maxPathLen"), "only allowed for CA certificates"))
+		}
+		if len(c.Spec.PermittedDNSDomains) != 0 {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("permittedDNSDomains"), "only allowed for CA certificates"))
+		}
+		if len(c.Spec.ExcludedDNSDomains) != 0 {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("excludedDNSDomains"), "only allowed for CA certificates"))
+		}
+	}
+	for i, domain := range c.Spec.PermittedDNSDomains {
+		if !isDNSSubdomain(strings.TrimPrefix(domain, ".")) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("permittedDNSDomains").Index(i), domain, "invalid DNS domain"))
+		}
+	}
+	for i, domain := range c.Spec.ExcludedDNSDomains {
+		if !isDNSSubdomain(strings.TrimPrefix(domain, ".")) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("excludedDNSDomains").Index(i), domain, "invalid DNS domain"))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+// reports whether the name is requested as one of the subject alternative names of the certificate
+func isSubjectAltName(c *certsv1.Certificate, name string) bool {
+	for _, dnsName := range certificateutil.DNSNames(&c.Spec) {
+		if strings.EqualFold(dnsName, name) {
+			return true
+		}
+	}
+	for _, addr := range c.Spec.IPAddresses {
+		if ip := net.ParseIP(addr); ip != nil && ip.Equal(net.ParseIP(name)) {
+			return true
+		}
+	}
+	for _, san := range append(c.Spec.URIs, c.Spec.EmailAddresses...) {
+		if san == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checks that validity or duration is in the correct format and that the
+// lifetime of the certificate is within the configured range
+func (v *Validator) validateDuration(c *certsv1.Certificate) error {
+	if c.Spec.Validity != "" && c.Spec.Duration != nil {
+		return field.Forbidden(field.NewPath("spec").Child("duration"), "validity and duration are mutually exclusive")
+	}
+	if c.Spec.Duration == nil {
+		if !certificateutil.IsValidity(c.Spec.Validity) {
+			return errors.New("invalid validity format, must be a positive integer followed by 'd'")
+		}
+	}
+
+	duration, err := certificateutil.CertificateDuration(&c.Spec)
+	if err != nil {
+		return fmt.Errorf("invalid validity format: %v", err)
+	}
+	limits := v.config().Webhook
+	if duration < limits.MinDuration.Duration || duration > limits.MaxDuration.Duration {
+		return fmt.Errorf("invalid validity, the certificate lifetime must be between %s and %s", limits.MinDuration.Duration, limits.MaxDuration.Duration)
+	}
+	return nil
+}
+
+// checks that the certificate is renewed before it expires
+func validateRenewBefore(c *certsv1.Certificate) error {
+	specPath := field.NewPath("spec")
+
+	if c.Spec.RenewBefore != nil && c.Spec.RenewBeforePercentage != nil {
+		return field.Forbidden(specPath.Child("renewBeforePercentage"), "renewBefore and renewBeforePercentage are mutually exclusive")
+	}
+	if c.Spec.RenewBefore != nil {
+		duration, err := certificateutil.CertificateDuration(&c.Spec)
+		if err != nil {
+			// an invalid validity is already reported by validateDuration
+			return nil
+		}
+		if c.Spec.RenewBefore.Duration <= 0 || c.Spec.RenewBefore.Duration >= duration {
+			return field.Invalid(specPath.Child("renewBefore"), c.Spec.RenewBefore.Duration.String(), "renewBefore must be positive and shorter than the validity of the certificate")
+		}
+	}
+	if p := c.Spec.RenewBeforePercentage; p != nil && (*p < 1 || *p > 99) {
+		return field.Invalid(specPath.Child("renewBeforePercentage"), *p, "renewBeforePercentage must be between 1 and 99")
+	}
+	return nil
+}
+
+// checks that the private key size and encoding are supported by the requested algorithm
+func validatePrivateKey(c *certsv1.Certificate) error {
+	if c.Spec.PrivateKey == nil {
+		return nil
+	}
+
+	privateKeyPath := field.NewPath("spec").Child("privateKey")
+	algorithm, size := certificateutil.KeyAlgorithm(c.Spec.PrivateKey)
+
+	switch certificateutil.KeyEncoding(c.Spec.PrivateKey) {
+	case certsv1.PKCS1KeyEncoding:
+		if algorithm == certsv1.Ed25519KeyAlgorithm {
+			return field.Invalid(privateKeyPath.Child("encoding"), c.Spec.PrivateKey.Encoding, "Ed25519 keys can only be encoded with PKCS8")
+		}
+	case certsv1.PKCS8KeyEncoding:
+	default:
+		return field.NotSupported(privateKeyPath.Child("encoding"), c.Spec.PrivateKey.Encoding,
+			[]string{string(certsv1.PKCS1KeyEncoding), string(certsv1.PKCS8KeyEncoding)})
+	}
+
+	switch policy := certificateutil.RotationPolicy(c.Spec.PrivateKey); policy {
+	case certsv1.AlwaysRotationPolicy, certsv1.NeverRotationPolicy:
+	default:
+		return field.NotSupported(privateKeyPath.Child("rotationPolicy"), policy,
+			[]certsv1.PrivateKeyRotationPolicy{certsv1.AlwaysRotationPolicy, certsv1.NeverRotationPolicy})
+	}
+
+	switch algorithm {
+	case certsv1.RSAKeyAlgorithm:
+		if size != 2048 && size != 3072 && size != 4096 {
+			return field.NotSupported(privateKeyPath.Child("size"), size, []string{"2048", "3072", "4096"})
+		}
+	case certsv1.ECDSAKeyAlgorithm:
+		if size != 256 && size != 384 {
+			return field.NotSupported(privateKeyPath.Child("size"), size, []string{"256", "384"})
+		}
+	case certsv1.Ed25519KeyAlgorithm:
+		if c.Spec.PrivateKey.Size != 0 {
+			return field.Invalid(privateKeyPath.Child("size"), c.Spec.PrivateKey.Size, "size must not be set for Ed25519 keys")
+		}
+	default:
+		return field.NotSupported(privateKeyPath.Child("algorithm"), algorithm,
+			[]string{string(certsv1.RSAKeyAlgorithm), string(certsv1.ECDSAKeyAlgorithm), string(certsv1.Ed25519KeyAlgorithm)})
 	}
 	return nil
 }
 
-// checks that validity is in the correct format and range.
-func validateValidity(c *certsv1.Certificate) error {
-	match, _ := regexp.MatchString(validityRegex, c.Spec.Validity)
-	if !match {
-		return errors.New("invalid validity format, must be a positive integer followed by 'd'")
+// checks that the usages are supported, unique and consistent with the private key
+// and with the certificate being a CA or not
+func validateUsages(c *certsv1.Certificate) error {
+	usagesPath := field.NewPath("spec").Child("usages")
+	algorithm, _ := certificateutil.KeyAlgorithm(c.Spec.PrivateKey)
+
+	var allErrs field.ErrorList
+	for i, usage := range c.Spec.Usages {
+		switch {
+		case !certificateutil.IsKeyUsage(usage):
+			allErrs = append(allErrs, field.NotSupported[string](usagesPath.Index(i), usage, nil))
+		case slices.Contains(c.Spec.Usages[:i], usage):
+			allErrs = append(allErrs, field.Duplicate(usagesPath.Index(i), usage))
+		case (usage == certsv1.UsageKeyEncipherment || usage == certsv1.UsageDataEncipherment) && algorithm != certsv1.RSAKeyAlgorithm:
+			allErrs = append(allErrs, field.Invalid(usagesPath.Index(i), usage, "encipherment is only supported by RSA keys"))
+		case usage == certsv1.UsageKeyAgreement && algorithm != certsv1.ECDSAKeyAlgorithm:
+			allErrs = append(allErrs, field.Invalid(usagesPath.Index(i), usage, "key agreement is only supported by ECDSA keys"))
+		case (usage == certsv1.UsageEncipherOnly || usage == certsv1.UsageDecipherOnly) && !slices.Contains(c.Spec.Usages, certsv1.UsageKeyAgreement):
+			allErrs = append(allErrs, field.Invalid(usagesPath.Index(i), usage, "requires the key agreement usage"))
+		case (usage == certsv1.UsageCertSign || usage == certsv1.UsageCRLSign) && !c.Spec.IsCA:
+			allErrs = append(allErrs, field.Invalid(usagesPath.Index(i), usage, "only allowed for CA certificates"))
+		}
 	}
+	return allErrs.ToAggregate()
+}
 
-	// Extract the integer part of validity and check the range (0 - 1825)
-	daysStr := strings.TrimSuffix(c.Spec.Validity, "d")
-	days, err := strconv.Atoi(daysStr)
-	if err != nil || days < 1 || days > 1825 {
-		return errors.New("invalid validity format, validity must be between 1 and 1825 days")
+// checks that the issuer reference names an Issuer or a ClusterIssuer
+func validateIssuerRef(c *certsv1.Certificate) error {
+	ref := c.Spec.IssuerRef
+	if ref == nil {
+		return nil
 	}
 
+	issuerRefPath := field.NewPath("spec").Child("issuerRef")
+	if ref.Name == "" {
+		return field.Required(issuerRefPath.Child("name"), "issuer name is required")
+	}
+	if ref.Kind != "" && ref.Kind != certsv1.IssuerKind && ref.Kind != certsv1.ClusterIssuerKind {
+		return field.NotSupported(issuerRefPath.Child("kind"), ref.Kind, []string{certsv1.IssuerKind, certsv1.ClusterIssuerKind})
+	}
 	return nil
 }
 
-func validateSecretName(client client.Client, c *certsv1.Certificate) error {
+// checks that every keystore to create references the secret key holding its password
+func validateKeystores(c *certsv1.Certificate) error {
+	keystores := c.Spec.Keystores
+	if keystores == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	keystoresPath := field.NewPath("spec").Child("keystores")
+	if keystores.PKCS12 != nil && keystores.PKCS12.Create {
+		pkcs12Path := keystoresPath.Child("pkcs12")
+		allErrs = append(allErrs, validatePasswordSecretRef(pkcs12Path.Child("passwordSecretRef"), keystores.PKCS12.PasswordSecretRef)...)
+		switch keystores.PKCS12.Profile {
+		case "", certsv1.LegacyRC2PKCS12Profile, certsv1.LegacyDESPKCS12Profile, certsv1.Modern2023PKCS12Profile:
+		default:
+			allErrs = append(allErrs, field.NotSupported(pkcs12Path.Child("profile"), keystores.PKCS12.Profile,
+				[]certsv1.PKCS12Profile{certsv1.LegacyRC2PKCS12Profile, certsv1.LegacyDESPKCS12Profile, certsv1.Modern2023PKCS12Profile}))
+		}
+	}
+	if keystores.JKS != nil && keystores.JKS.Create {
+		allErrs = append(allErrs, validatePasswordSecretRef(keystoresPath.Child("jks").Child("passwordSecretRef"), keystores.JKS.PasswordSecretRef)...)
+	}
+	return allErrs.ToAggregate()
+}
+
+// checks that the certificate does not use features disabled by the feature gates
+func (v *Validator) validateFeatureGates(c *certsv1.Certificate) error {
+	featureGates := v.config().FeatureGates
+	keystores := c.Spec.Keystores
+	if keystores == nil || featureGates.Enabled(configv1alpha1.KeystoresFeature) {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	keystoresPath := field.NewPath("spec").Child("keystores")
+	if keystores.PKCS12 != nil && keystores.PKCS12.Create {
+		allErrs = append(allErrs, field.Forbidden(keystoresPath.Child("pkcs12"), "the Keystores feature gate is disabled"))
+	}
+	if keystores.JKS != nil && keystores.JKS.Create {
+		allErrs = append(allErrs, field.Forbidden(keystoresPath.Child("jks"), "the Keystores feature gate is disabled"))
+	}
+	return allErrs.ToAggregate()
+}
+
+func validatePasswordSecretRef(path *field.Path, ref certsv1.SecretKeySelector) field.ErrorList {
+	var allErrs field.ErrorList
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), "password secret name is required"))
+	}
+	if ref.Key == "" {
+		allErrs = append(allErrs, field.Required(path.Child("key"), "password secret key is required"))
+	}
+	return allErrs
+}
+
+// checks that the custom key names are valid, distinct and not used by additional outputs,
+// that every additional output format is requested once and that the labels and annotations
+// are valid and do not override the annotations used by certaur
+func validateSecretTemplate(c *certsv1.Certificate) error {
+	template := c.Spec.SecretTemplate
+	if template == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	templatePath := field.NewPath("spec").Child("secretTemplate")
+	if template.Keys != nil {
+		keysPath := templatePath.Child("keys")
+		seen := map[string]bool{}
+		for _, key := range []struct {
+			name  string
+			value string
+		}{
+			{"certificate", template.Keys.Certificate},
+			{"privateKey", template.Keys.PrivateKey},
+			{"ca", template.Keys.CA},
+		} {
+			if key.value == "" {
+				continue
+			}
+			keyPath := keysPath.Child(key.name)
+			for _, msg := range validation.IsConfigMapKey(key.value) {
+				allErrs = append(allErrs, field.Invalid(keyPath, key.value, msg))
+			}
+			if slices.Contains(secretutil.ReservedKeys, key.value) {
+				allErrs = append(allErrs, field.Invalid(keyPath, key.value, "reserved for additional outputs and keystores"))
+			}
+			if seen[key.value] {
+				allErrs = append(allErrs, field.Duplicate(keyPath, key.value))
+			}
+			seen[key.value] = true
+		}
+	}
+
+	formats := map[certsv1.CertificateOutputFormatType]bool{}
+	for i, format := range template.AdditionalOutputFormats {
+		formatPath := templatePath.Child("additionalOutputFormats").Index(i).Child("type")
+		switch format.Type {
+		case certsv1.CombinedPEMOutputFormat, certsv1.DEROutputFormat:
+		default:
+			allErrs = append(allErrs, field.NotSupported(formatPath, format.Type,
+				[]certsv1.CertificateOutputFormatType{certsv1.CombinedPEMOutputFormat, certsv1.DEROutputFormat}))
+			continue
+		}
+		if formats[format.Type] {
+			allErrs = append(allErrs, field.Duplicate(formatPath, format.Type))
+		}
+		formats[format.Type] = true
+	}
+
+	allErrs = append(allErrs, metav1validation.ValidateLabels(template.Labels, templatePath.Child("labels"))...)
+	if _, exists := template.Labels[secretutil.ManagedLabel]; exists {
+		allErrs = append(allErrs, field.Forbidden(templatePath.Child("labels").Key(secretutil.ManagedLabel), "reserved for certaur"))
+	}
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(template.Annotations, templatePath.Child("annotations"))...)
+	for _, key := range []string{secretutil.ManagedKeysAnnotation, secretutil.ManagedLabelsAnnotation, secretutil.ManagedAnnotationsAnnotation, secretutil.PKCS12ProfileAnnotation} {
+		if _, exists := template.Annotations[key]; exists {
+			allErrs = append(allErrs, field.Forbidden(templatePath.Child("annotations").Key(key), "reserved for certaur"))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+// checks that the secret does not exist yet, unless it is already owned by the certificate or can be adopted by it
+// when secret adoption is allowed
+func validateSecretName(client client.Client, c *certsv1.Certificate, allowAdoption bool) error {
 	ctx := context.Background()
 
 	// check if the secret already exists
 	secret := &corev1.Secret{}
 	secretNamespacedName := types.NamespacedName{Name: c.Spec.SecretRef.Name, Namespace: c.Namespace}
 	err := client.Get(ctx, secretNamespacedName, secret)
-	if err == nil {
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return field.InternalError(field.NewPath("spec").Child("secretRef").Child("name"), fmt.Errorf("failed to get secret: %w", err))
+	}
+	if secretutil.IsOwnerReference(c, secret) {
+		return nil
+	}
+	if !allowAdoption {
 		return errors.New("secret already exists")
 	}
+	if !secretutil.IsAdoptable(secret) {
+		return fmt.Errorf("secret already exists, annotate it with %s=true to adopt it", secretutil.AdoptionAnnotation)
+	}
 	return nil
 }
 
@@ -2,16 +2,28 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	certsv1 "github.com/AKI-25/certaur/pkg/api/v1"
+	configv1alpha1 "github.com/AKI-25/certaur/pkg/config/v1alpha1"
+	controller "github.com/AKI-25/certaur/pkg/controllers/certificate"
+	secretutil "github.com/AKI-25/certaur/pkg/util/secret"
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 var (
@@ -57,6 +69,130 @@ func TestCertificateWebhook(t *testing.T) {
 		// Assert that defaults are set
 		assert.Equal(t, "365d", cert.Spec.Validity)
 		assert.Equal(t, fmt.Sprintf("%s-secret", cert.Name), cert.Spec.SecretRef.Name)
+		assert.Equal(t, &certsv1.CertificatePrivateKey{
+			Algorithm:      certsv1.RSAKeyAlgorithm,
+			Size:           2048,
+			Encoding:       certsv1.PKCS1KeyEncoding,
+			RotationPolicy: certsv1.AlwaysRotationPolicy,
+		}, cert.Spec.PrivateKey)
+		assert.Equal(t, certsv1.DeleteSecretDeletionPolicy, cert.Spec.SecretDeletionPolicy)
+	})
+
+	t.Run("should apply the configured defaults", func(t *testing.T) {
+		cfg := configv1alpha1.New()
+		cfg.Defaults.Validity = "90d"
+		cfg.Defaults.PrivateKey = configv1alpha1.PrivateKeyDefaults{Algorithm: certsv1.ECDSAKeyAlgorithm, Size: 384}
+		cfg.Defaults.SecretDeletionPolicy = certsv1.RetainSecretDeletionPolicy
+		renewBeforePercentage := int32(20)
+		cfg.Renewal.RenewBeforePercentage = &renewBeforePercentage
+		configured := Validator{client: fakeClient, scheme: scheme, Config: cfg}
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName: "test.example.com",
+			},
+		}
+		err := configured.Default(ctx, cert)
+		require.NoError(t, err)
+
+		assert.Equal(t, "90d", cert.Spec.Validity)
+		assert.Equal(t, certsv1.ECDSAKeyAlgorithm, cert.Spec.PrivateKey.Algorithm)
+		assert.Equal(t, 384, cert.Spec.PrivateKey.Size)
+		assert.Equal(t, certsv1.RetainSecretDeletionPolicy, cert.Spec.SecretDeletionPolicy)
+		assert.Equal(t, &renewBeforePercentage, cert.Spec.RenewBeforePercentage)
+
+		// Values set on the certificate are kept, a size without algorithm still refers to an RSA key
+		cert = &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:     "test.example.com",
+				Validity:    "30d",
+				PrivateKey:  &certsv1.CertificatePrivateKey{Size: 4096},
+				RenewBefore: &metav1.Duration{Duration: 24 * time.Hour},
+			},
+		}
+		err = configured.Default(ctx, cert)
+		require.NoError(t, err)
+
+		assert.Equal(t, "30d", cert.Spec.Validity)
+		assert.Equal(t, certsv1.RSAKeyAlgorithm, cert.Spec.PrivateKey.Algorithm)
+		assert.Equal(t, 4096, cert.Spec.PrivateKey.Size)
+		assert.Nil(t, cert.Spec.RenewBeforePercentage)
+	})
+
+	t.Run("should default the size of ECDSA keys", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:    "test.example.com",
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.ECDSAKeyAlgorithm},
+			},
+		}
+
+		err := v.Default(ctx, cert)
+		require.NoError(t, err)
+
+		assert.Equal(t, 256, cert.Spec.PrivateKey.Size)
+	})
+
+	t.Run("should reject unsupported private key sizes", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:    "valid.example.com",
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.RSAKeyAlgorithm, Size: 1024},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "spec.privateKey.size: Unsupported value: 1024")
+
+		cert.Spec.PrivateKey = &certsv1.CertificatePrivateKey{Algorithm: certsv1.ECDSAKeyAlgorithm, Size: 521}
+		warnings, err = v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "spec.privateKey.size: Unsupported value: 521")
+	})
+
+	t.Run("should reject PKCS1 encoding for Ed25519 keys", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName: "valid.example.com",
+				PrivateKey: &certsv1.CertificatePrivateKey{
+					Algorithm: certsv1.Ed25519KeyAlgorithm,
+					Encoding:  certsv1.PKCS1KeyEncoding,
+				},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "Ed25519 keys can only be encoded with PKCS8")
 	})
 
 	t.Run("should reject invalid DNS names", func(t *testing.T) {
@@ -82,6 +218,210 @@ func TestCertificateWebhook(t *testing.T) {
 		assert.Contains(t, warnings[0], "invalid DNS name")
 	})
 
+	t.Run("should merge dnsName into dnsNames", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:  "test.example.com",
+				DNSNames: []string{"test.default.svc.cluster.local", "test.example.com"},
+			},
+		}
+
+		err := v.Default(ctx, cert)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"test.example.com", "test.default.svc.cluster.local"}, cert.Spec.DNSNames)
+	})
+
+	t.Run("should accept service names and wildcards", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DNSNames: []string{"my-svc", "my-svc.default", "my-svc.default.svc.cluster.local", "*.example.com", "Example.COM"},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		_, err := v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+
+		cert.Spec.DNSNames = []string{"*", "*.*.example.com", "my_svc", "-my-svc.default"}
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		for i := range cert.Spec.DNSNames {
+			assert.Contains(t, strings.Join(warnings, "\n"), fmt.Sprintf("spec.dnsNames[%d]", i))
+		}
+	})
+
+	t.Run("should reject invalid entries in dnsNames", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DNSNames: []string{"valid.example.com", "invalid_dns_name"},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "spec.dnsNames[1]")
+		assert.Contains(t, warnings[0], "invalid DNS name")
+	})
+
+	t.Run("should reject certificates without subject alternative names", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "at least one subject alternative name is required")
+	})
+
+	t.Run("should reject invalid IP, URI and email SANs", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				IPAddresses:    []string{"10.0.0.1", "10.0.0.256"},
+				URIs:           []string{"spiffe://cluster.local/ns/default/sa/test", "not a uri"},
+				EmailAddresses: []string{"admin@example.com", "Admin <admin@example.com>"},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "spec.ipAddresses[1]")
+		assert.Contains(t, warnings[0], "spec.uris[1]")
+		assert.Contains(t, warnings[0], "spec.emailAddresses[1]")
+	})
+
+	t.Run("should accept certificates with only IP and URI SANs", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				IPAddresses: []string{"10.96.0.10", "fd00::10"},
+				URIs:        []string{"spiffe://cluster.local/ns/default/sa/test"},
+				SecretRef: certsv1.SecretReference{
+					Name: "ip-secret",
+				},
+				Validity: "365d",
+			},
+		}
+
+		_, err := v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject a common name that is not a SAN", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DNSNames: []string{"valid.example.com"},
+				Subject: &certsv1.X509Subject{
+					CommonName: "other.example.com",
+					Countries:  []string{"Tunisia"},
+				},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "common name must be one of the subject alternative names")
+		assert.Contains(t, warnings[0], "spec.subject.countries[0]")
+	})
+
+	t.Run("should reject a common name longer than 64 characters", func(t *testing.T) {
+		commonName := strings.Repeat("a", 60) + ".example.com"
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DNSNames: []string{commonName},
+				Subject: &certsv1.X509Subject{
+					CommonName: commonName,
+				},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "spec.subject.commonName: Too long")
+	})
+
+	t.Run("should reject renewBefore exceeding the validity", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:     "valid.example.com",
+				RenewBefore: &metav1.Duration{Duration: 48 * time.Hour},
+				SecretRef: certsv1.SecretReference{
+					Name: testSecretName,
+				},
+				Validity: "1d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "renewBefore must be positive and shorter than the validity of the certificate")
+
+		percentage := int32(30)
+		cert.Spec.RenewBeforePercentage = &percentage
+		warnings, err = v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "renewBefore and renewBeforePercentage are mutually exclusive")
+	})
+
 	t.Run("should reject invalid validity values", func(t *testing.T) {
 		// Create a certificate with an invalid validity
 		cert := &certsv1.Certificate{
@@ -101,6 +441,219 @@ func TestCertificateWebhook(t *testing.T) {
 		assert.Contains(t, warnings[0], "invalid validity format")
 	})
 
+	t.Run("should accept durations within the configured range", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:  "valid.example.com",
+				Duration: &metav1.Duration{Duration: 15 * time.Minute},
+			},
+		}
+
+		// Durations are not overridden by the default validity
+		err := v.Default(ctx, cert)
+		require.NoError(t, err)
+		assert.Empty(t, cert.Spec.Validity)
+		_, err = v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+
+		cert.Spec.Duration.Duration = time.Minute
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "the certificate lifetime must be between 5m0s and 43800h0m0s")
+
+		cert.Spec.Validity = "30d"
+		warnings, err = v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "validity and duration are mutually exclusive")
+
+		// The range is configurable
+		cfg := configv1alpha1.New()
+		cfg.Webhook.MaxDuration.Duration = 90 * 24 * time.Hour
+		restricted := Validator{client: fakeClient, scheme: scheme, Config: cfg}
+		cert.Spec.Duration = nil
+		_, err = restricted.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+		cert.Spec.Validity = "365d"
+		_, err = restricted.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+	})
+
+	t.Run("should default and validate the issuer reference", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:   "valid.example.com",
+				IssuerRef: &certsv1.IssuerReference{Name: "ca-issuer"},
+			},
+		}
+
+		err := v.Default(ctx, cert)
+		require.NoError(t, err)
+		assert.Equal(t, certsv1.IssuerKind, cert.Spec.IssuerRef.Kind)
+
+		cert.Spec.IssuerRef.Name = ""
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "issuer name is required")
+	})
+
+	t.Run("should default and validate usages", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DnsName:    "client.example.com",
+				PrivateKey: &certsv1.CertificatePrivateKey{Algorithm: certsv1.ECDSAKeyAlgorithm},
+				Usages:     []certsv1.KeyUsage{certsv1.UsageDigitalSignature, certsv1.UsageKeyEncipherment, certsv1.UsageClientAuth},
+			},
+		}
+
+		// Usages are not rewritten by the defaulter, key encipherment is rejected for ECDSA keys instead
+		err := v.Default(ctx, cert)
+		require.NoError(t, err)
+		assert.Equal(t, []certsv1.KeyUsage{certsv1.UsageDigitalSignature, certsv1.UsageKeyEncipherment, certsv1.UsageClientAuth}, cert.Spec.Usages)
+
+		cert.Spec.Usages = append(cert.Spec.Usages, certsv1.UsageClientAuth, certsv1.UsageDataEncipherment, certsv1.UsageCertSign)
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, warnings[0], "spec.usages[1]: Invalid value: \"key encipherment\": encipherment is only supported by RSA keys")
+		assert.Contains(t, warnings[0], "spec.usages[3]: Duplicate value")
+		assert.Contains(t, warnings[0], "spec.usages[4]: Invalid value: \"data encipherment\": encipherment is only supported by RSA keys")
+		assert.Contains(t, warnings[0], "spec.usages[5]: Invalid value: \"cert sign\": only allowed for CA certificates")
+	})
+
+	t.Run("should only accept CA constraints on CA certificates", func(t *testing.T) {
+		maxPathLen := int32(1)
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				Subject:             &certsv1.X509Subject{CommonName: "certaur intermediate CA"},
+				MaxPathLen:          &maxPathLen,
+				PermittedDNSDomains: []string{".example.com", "invalid_domain"},
+				Validity:            "365d",
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.maxPathLen: Forbidden: only allowed for CA certificates")
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.permittedDNSDomains[1]: Invalid value")
+
+		// CA certificates identified by their common name do not need any SAN
+		cert.Spec.IsCA = true
+		cert.Spec.PermittedDNSDomains = []string{".example.com"}
+		cert.Spec.SecretRef.Name = "intermediate-ca-secret"
+		_, err = v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should require keystore passwords", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DNSNames: []string{"java.example.com"},
+				Validity: "365d",
+				Keystores: &certsv1.CertificateKeystores{
+					PKCS12: &certsv1.PKCS12Keystore{Create: true, Profile: "Legacy"},
+					JKS:    &certsv1.JKSKeystore{Create: true, PasswordSecretRef: certsv1.SecretKeySelector{Name: "keystore-password"}},
+				},
+				SecretRef: certsv1.SecretReference{Name: "keystore-secret"},
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.keystores.pkcs12.passwordSecretRef.name: Required value")
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.keystores.pkcs12.profile: Unsupported value: \"Legacy\"")
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.keystores.jks.passwordSecretRef.key: Required value")
+
+		passwordRef := certsv1.SecretKeySelector{Name: "keystore-password", Key: "password"}
+		cert.Spec.Keystores.PKCS12 = &certsv1.PKCS12Keystore{Create: true, PasswordSecretRef: passwordRef, Profile: certsv1.LegacyDESPKCS12Profile}
+		cert.Spec.Keystores.JKS.PasswordSecretRef = passwordRef
+		_, err = v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+
+		// Keystores are rejected when their feature gate is disabled
+		cfg := configv1alpha1.New()
+		cfg.FeatureGates[configv1alpha1.KeystoresFeature] = false
+		gated := Validator{client: fakeClient, scheme: scheme, Config: cfg}
+		warnings, err = gated.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.keystores.pkcs12: Forbidden: the Keystores feature gate is disabled")
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.keystores.jks: Forbidden: the Keystores feature gate is disabled")
+	})
+
+	t.Run("should validate the secret template", func(t *testing.T) {
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DNSNames: []string{"haproxy.example.com"},
+				Validity: "365d",
+				SecretTemplate: &certsv1.CertificateSecretTemplate{
+					Keys: &certsv1.CertificateSecretKeys{Certificate: "cert.pem", PrivateKey: "cert.pem", CA: "key.der"},
+					AdditionalOutputFormats: []certsv1.CertificateAdditionalOutputFormat{
+						{Type: certsv1.CombinedPEMOutputFormat},
+						{Type: certsv1.CombinedPEMOutputFormat},
+					},
+				},
+				SecretRef: certsv1.SecretReference{Name: "haproxy-secret"},
+			},
+		}
+
+		warnings, err := v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretTemplate.keys.privateKey: Duplicate value: \"cert.pem\"")
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretTemplate.keys.ca: Invalid value: \"key.der\": reserved for additional outputs and keystores")
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretTemplate.additionalOutputFormats[1].type: Duplicate value")
+
+		cert.Spec.SecretTemplate.Keys = &certsv1.CertificateSecretKeys{Certificate: "cert/pem"}
+		warnings, err = v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretTemplate.keys.certificate: Invalid value: \"cert/pem\"")
+
+		cert.Spec.SecretTemplate.Keys = nil
+		cert.Spec.SecretTemplate.Labels = map[string]string{"invalid label": "value"}
+		cert.Spec.SecretTemplate.Annotations = map[string]string{"certs.k8c.io/managed-keys": "tls.crt"}
+		warnings, err = v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretTemplate.labels: Invalid value: \"invalid label\"")
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretTemplate.annotations[certs.k8c.io/managed-keys]: Forbidden: reserved for certaur")
+
+		cert.Spec.SecretTemplate.Labels = map[string]string{"certs.k8c.io/managed": "false"}
+		cert.Spec.SecretTemplate.Annotations = nil
+		warnings, err = v.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretTemplate.labels[certs.k8c.io/managed]: Forbidden: reserved for certaur")
+
+		cert.Spec.SecretTemplate.Labels = map[string]string{"app.kubernetes.io/name": "haproxy"}
+		cert.Spec.SecretTemplate.Annotations = map[string]string{"reflector.v1.k8s.emberstack.com/reflection-allowed": "true"}
+		cert.Spec.SecretTemplate.Keys = &certsv1.CertificateSecretKeys{Certificate: "cert.pem", PrivateKey: "key.pem"}
+		cert.Spec.SecretTemplate.AdditionalOutputFormats = []certsv1.CertificateAdditionalOutputFormat{
+			{Type: certsv1.CombinedPEMOutputFormat},
+			{Type: certsv1.DEROutputFormat},
+		}
+		_, err = v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+	})
+
 	t.Run("should reject existing secret names", func(t *testing.T) {
 		// Create a secret in the fake client
 		secret := &corev1.Secret{
@@ -131,6 +684,181 @@ func TestCertificateWebhook(t *testing.T) {
 		warnings, err := v.ValidateCreate(ctx, cert)
 		assert.Error(t, err)
 		assert.Contains(t, warnings[0], "secret already exists")
+
+		// Secrets retained after the deletion of their certificate can be adopted
+		secret.Labels = map[string]string{"certs.k8c.io/orphaned": "true"}
+		err = fakeClient.Update(ctx, secret)
+		require.NoError(t, err)
+		_, err = v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should accept adoptable and owned secrets", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "hand-made-secret",
+				Namespace:   "default",
+				Annotations: map[string]string{"certs.k8c.io/allow-adoption": "true"},
+			},
+		}
+		err := fakeClient.Create(ctx, secret)
+		require.NoError(t, err)
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      testCertName,
+				Namespace: "default",
+			},
+			Spec: certsv1.CertificateSpec{
+				DNSNames:  []string{"valid.example.com"},
+				SecretRef: certsv1.SecretReference{Name: "hand-made-secret"},
+				Validity:  "365d",
+			},
+		}
+		_, err = v.ValidateCreate(ctx, cert)
+		assert.NoError(t, err)
+
+		// Adoption is refused when its feature gate is disabled
+		cfg := configv1alpha1.New()
+		cfg.FeatureGates[configv1alpha1.SecretAdoptionFeature] = false
+		gated := Validator{client: fakeClient, scheme: scheme, Config: cfg}
+		warnings, err := gated.ValidateCreate(ctx, cert)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "secret already exists")
+
+		// Once adopted, updates of the certificate owning the secret are accepted
+		secret.Annotations = nil
+		secret.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(cert, certsv1.GroupVersion.WithKind("Certificate")),
+		}
+		err = fakeClient.Update(ctx, secret)
+		require.NoError(t, err)
+		_, err = v.ValidateUpdate(ctx, cert, cert)
+		assert.NoError(t, err)
+
+		// Secrets controlled by another certificate cannot be taken over
+		other := cert.DeepCopy()
+		other.Name = "other-cert"
+		warnings, err = v.ValidateCreate(ctx, other)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "secret already exists")
+
+		// Secrets that cannot be read are not assumed to be missing
+		failing := Validator{client: fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return apierrors.NewForbidden(corev1.Resource("secrets"), key.Name, errors.New("denied"))
+			},
+		}).Build(), scheme: scheme}
+		warnings, err = failing.ValidateCreate(ctx, other)
+		assert.Error(t, err)
+		assert.Contains(t, strings.Join(warnings, "\n"), "spec.secretRef.name: Internal error: failed to get secret")
+	})
+
+	t.Run("should not block the deletion of certificates retaining their secret", func(t *testing.T) {
+		// The webhook validates every update of a certificate made by the controller, with adoption
+		// disabled so that the orphaned secret would be refused as an existing secret
+		cfg := configv1alpha1.New()
+		cfg.FeatureGates[configv1alpha1.SecretAdoptionFeature] = false
+		var admitted Validator
+		admittedClient := fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&certsv1.Certificate{}).
+			WithIndex(&corev1.Secret{}, secretutil.OwnerUIDIndex, secretutil.IndexOwnerUID).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if cert, ok := obj.(*certsv1.Certificate); ok {
+						old := &certsv1.Certificate{}
+						if err := c.Get(ctx, client.ObjectKeyFromObject(cert), old); err != nil {
+							return err
+						}
+						if warnings, err := admitted.ValidateUpdate(ctx, old, cert); err != nil {
+							return fmt.Errorf("%w: %s", err, strings.Join(warnings, ", "))
+						}
+					}
+					return c.Update(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		admitted = Validator{client: admittedClient, scheme: scheme, Config: cfg}
+		reconciler := &controller.CertificateReconciler{
+			Client:   admittedClient,
+			Scheme:   scheme,
+			Logger:   logr.Discard(),
+			Recorder: record.NewFakeRecorder(100),
+		}
+
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "retained-cert", Namespace: "default"},
+			Spec: certsv1.CertificateSpec{
+				DNSNames:             []string{"retained.example.com"},
+				SecretRef:            certsv1.SecretReference{Name: "retained-secret"},
+				SecretDeletionPolicy: certsv1.RetainSecretDeletionPolicy,
+			},
+		}
+		require.NoError(t, admitted.Default(ctx, cert))
+		_, err := admitted.ValidateCreate(ctx, cert)
+		require.NoError(t, err)
+		require.NoError(t, admittedClient.Create(ctx, cert))
+
+		// The finalizer is added and the secret is created
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cert)}
+		for range 2 {
+			_, err = reconciler.Reconcile(ctx, req)
+			require.NoError(t, err)
+		}
+		secret := &corev1.Secret{}
+		require.NoError(t, admittedClient.Get(ctx, client.ObjectKey{Name: "retained-secret", Namespace: "default"}, secret))
+
+		// The finalizer is removed once the secret is orphaned, although the secret is no longer owned
+		require.NoError(t, admittedClient.Delete(ctx, cert))
+		_, err = reconciler.Reconcile(ctx, req)
+		assert.NoError(t, err)
+		err = admittedClient.Get(ctx, req.NamespacedName, cert)
+		assert.True(t, apierrors.IsNotFound(err))
+		require.NoError(t, admittedClient.Get(ctx, client.ObjectKey{Name: "retained-secret", Namespace: "default"}, secret))
+		assert.Empty(t, secret.OwnerReferences)
+	})
+
+	t.Run("should only check changed fields against the configuration on update", func(t *testing.T) {
+		err := fakeClient.Create(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "taken-secret", Namespace: "default"}})
+		require.NoError(t, err)
+		cert := &certsv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: testCertName, Namespace: "default"},
+			Spec: certsv1.CertificateSpec{
+				DNSNames:  []string{"valid.example.com"},
+				SecretRef: certsv1.SecretReference{Name: "existing-secret"},
+				Validity:  "365d",
+				Keystores: &certsv1.CertificateKeystores{
+					PKCS12: &certsv1.PKCS12Keystore{Create: true, PasswordSecretRef: certsv1.SecretKeySelector{Name: "password", Key: "password"}},
+				},
+			},
+		}
+
+		// Certificates accepted before the limits were tightened and the feature gates were disabled stay updatable
+		cfg := configv1alpha1.New()
+		cfg.Webhook.MaxDuration = metav1.Duration{Duration: 30 * 24 * time.Hour}
+		cfg.FeatureGates[configv1alpha1.KeystoresFeature] = false
+		cfg.FeatureGates[configv1alpha1.SecretAdoptionFeature] = false
+		restricted := Validator{client: fakeClient, scheme: scheme, Config: cfg}
+		updated := cert.DeepCopy()
+		updated.Spec.DNSNames = append(updated.Spec.DNSNames, "www.valid.example.com")
+		_, err = restricted.ValidateUpdate(ctx, cert, updated)
+		assert.NoError(t, err)
+
+		// The changed fields are checked
+		updated = cert.DeepCopy()
+		updated.Spec.Validity = "90d"
+		updated.Spec.Keystores.PKCS12.Profile = certsv1.Modern2023PKCS12Profile
+		updated.Spec.SecretRef.Name = "taken-secret"
+		warnings, err := restricted.ValidateUpdate(ctx, cert, updated)
+		assert.Error(t, err)
+		assert.Len(t, warnings, 3)
+
+		// Certificates being deleted are not checked at all
+		updated = cert.DeepCopy()
+		updated.Spec.DNSNames = nil
+		updated.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		_, err = restricted.ValidateUpdate(ctx, cert, updated)
+		assert.NoError(t, err)
 	})
 
 	t.Run("should accept valid certificate requests", func(t *testing.T) {
@@ -143,6 +871,11 @@ func TestCertificateWebhook(t *testing.T) {
 			Spec: certsv1.CertificateSpec{
 				DnsName:  "valid.example.com",
 				Validity: "365d",
+				Subject: &certsv1.X509Subject{
+					CommonName:    "valid.example.com",
+					Organizations: []string{"certaur"},
+					Countries:     []string{"TN"},
+				},
 				SecretRef: certsv1.SecretReference{
 					Name: "new-secret",
 				},